@@ -0,0 +1,89 @@
+package snakepit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/solher/arangolite"
+)
+
+const defaultSeedBatchSize = 1000
+
+// BatchSize sets the maximum number of seed documents or keys sent in a single request.
+// Large seed collections are split in batches of this size.
+func (d *ArangoDBManager) BatchSize(size int) *ArangoDBManager {
+	d.batchSize = size
+	return d
+}
+
+// Progress sets the writer where the seeding progress is reported.
+func (d *ArangoDBManager) Progress(w io.Writer) *ArangoDBManager {
+	d.progress = w
+	return d
+}
+
+func (d *ArangoDBManager) seedBatchSize() int {
+	if d.batchSize <= 0 {
+		return defaultSeedBatchSize
+	}
+
+	return d.batchSize
+}
+
+func (d *ArangoDBManager) progressf(format string, args ...interface{}) {
+	if d.progress != nil {
+		fmt.Fprintf(d.progress, format+"\n", args...)
+	}
+}
+
+// seedBatches splits a seed slice in consecutive slices of at most size elements.
+func seedBatches(field reflect.Value, size int) []interface{} {
+	batches := []interface{}{}
+
+	for start := 0; start < field.Len(); start += size {
+		end := start + size
+		if end > field.Len() {
+			end = field.Len()
+		}
+
+		batches = append(batches, field.Slice(start, end).Interface())
+	}
+
+	return batches
+}
+
+// runPaged runs a query through a cursor, appending each page of results to the
+// slice pointed by dst, so that the full response is never decoded at once.
+func (d *ArangoDBManager) runPaged(q *arangolite.Query, dst reflect.Value) error {
+	slice := dst.Elem()
+
+	return d.runCursor(q, d.seedBatchSize(), func(page []byte) error {
+		docs := reflect.New(slice.Type())
+
+		if err := json.Unmarshal(page, docs.Interface()); err != nil {
+			return err
+		}
+
+		slice.Set(reflect.AppendSlice(slice, docs.Elem()))
+		return nil
+	})
+}
+
+// runCursor runs a query through a cursor of the given batch size, calling fn
+// with each page of results, a JSON array.
+func (d *ArangoDBManager) runCursor(q *arangolite.Query, batchSize int, fn func(page []byte) error) error {
+	async, err := d.db.RunAsync(q.BatchSize(batchSize))
+	if err != nil {
+		return err
+	}
+
+	for async.HasMore() {
+		if err := fn(async.Buffer().Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package snakepit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/solher/arangolite"
+)
+
+// SeedDriftError is returned when the distant seed does not match the local one.
+// It details, per collection, the missing documents and the fields that differ.
+type SeedDriftError struct {
+	Collections []CollectionDrift `json:"collections"`
+}
+
+// CollectionDrift describes the differences in a seed collection.
+type CollectionDrift struct {
+	Name string `json:"name"`
+	// The keys of the local documents missing in the database.
+	Missing []string `json:"missing,omitempty"`
+	// The number of local documents ignored because of an empty key.
+	Unkeyed int `json:"unkeyed,omitempty"`
+	// The local documents differing from their distant version.
+	Changed []DocumentDrift `json:"changed,omitempty"`
+}
+
+// DocumentDrift describes the differences of a seed document.
+type DocumentDrift struct {
+	Key    string       `json:"key"`
+	Fields []FieldDrift `json:"fields"`
+}
+
+// FieldDrift holds the distant (before) and local (after) values of a field.
+type FieldDrift struct {
+	Name    string      `json:"name"`
+	Distant interface{} `json:"distant"`
+	Local   interface{} `json:"local"`
+}
+
+func (e *SeedDriftError) Error() string {
+	names := make([]string, len(e.Collections))
+	for i, c := range e.Collections {
+		names[i] = c.Name
+	}
+
+	return fmt.Sprintf("seeds not synchronized: %s", strings.Join(names, ", "))
+}
+
+// Print writes a human readable report of the drift to w.
+func (e *SeedDriftError) Print(w io.Writer) {
+	for _, c := range e.Collections {
+		fmt.Fprintf(w, "%s:\n", c.Name)
+
+		for _, key := range c.Missing {
+			fmt.Fprintf(w, "  - missing %s\n", key)
+		}
+
+		if c.Unkeyed > 0 {
+			fmt.Fprintf(w, "  - %d document(s) without key\n", c.Unkeyed)
+		}
+
+		for _, doc := range c.Changed {
+			fmt.Fprintf(w, "  ~ changed %s\n", doc.Key)
+
+			for _, f := range doc.Fields {
+				distant, _ := json.Marshal(f.Distant)
+				local, _ := json.Marshal(f.Local)
+				fmt.Fprintf(w, "      %s: %s => %s\n", f.Name, distant, local)
+			}
+		}
+	}
+}
+
+// collectionDrift compares a local seed collection with its distant version.
+func (d *ArangoDBManager) collectionDrift(colName string, seed interface{}, keyOnly bool) (*CollectionDrift, error) {
+	distants := []struct {
+		Key     string                 `json:"key"`
+		Distant map[string]interface{} `json:"distant"`
+	}{}
+
+	for _, batch := range seedBatches(reflect.ValueOf(seed), d.seedBatchSize()) {
+		q := arangolite.NewQuery(`
+			FOR y IN @seed != null ? @seed : []
+			FILTER y._key != "" && y._key != NULL
+			LET x = DOCUMENT(CONCAT(@colName, "/", y._key))
+			RETURN { key: y._key, distant: x == NULL ? NULL : UNSET(x,"_id","_rev") }
+		`).Bind("seed", batch).Bind("colName", colName)
+
+		if err := d.runPaged(q, reflect.ValueOf(&distants)); err != nil {
+			return nil, err
+		}
+	}
+
+	byKey := make(map[string]map[string]interface{}, len(distants))
+	for _, doc := range distants {
+		if doc.Distant != nil {
+			byKey[doc.Key] = doc.Distant
+		}
+	}
+
+	return seedDrift(colName, seed, keyOnly, byKey)
+}
+
+// seedDrift compares a local seed collection with the distant documents having the same keys.
+func seedDrift(colName string, seed interface{}, keyOnly bool, distants map[string]map[string]interface{}) (*CollectionDrift, error) {
+	locals, err := toDocuments(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &CollectionDrift{Name: colName}
+
+	for _, local := range locals {
+		key, _ := local["_key"].(string)
+		if key == "" {
+			drift.Unkeyed++
+			continue
+		}
+
+		distant, ok := distants[key]
+		if !ok {
+			drift.Missing = append(drift.Missing, key)
+			continue
+		}
+
+		if keyOnly {
+			continue
+		}
+
+		names := make([]string, 0, len(local))
+		for name := range local {
+			if name != "_id" && name != "_rev" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		fields := []FieldDrift{}
+
+		for _, name := range names {
+			if !reflect.DeepEqual(local[name], distant[name]) {
+				fields = append(fields, FieldDrift{Name: name, Distant: distant[name], Local: local[name]})
+			}
+		}
+
+		if len(fields) > 0 {
+			drift.Changed = append(drift.Changed, DocumentDrift{Key: key, Fields: fields})
+		}
+	}
+
+	return drift, nil
+}
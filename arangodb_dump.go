@@ -0,0 +1,267 @@
+package snakepit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/solher/arangolite"
+)
+
+const (
+	dumpBatchSize = 1000
+	dumpManifest  = "manifest.json"
+)
+
+type dumpedCollection struct {
+	Name string `json:"name"`
+	Type int    `json:"type"`
+}
+
+// Dump writes the collections of the database to dir, one newline delimited JSON file
+// per collection. Only the seed collections are dumped, unless all is true.
+// Documents are read by batches so that large collections are never fully loaded in memory.
+func (d *ArangoDBManager) Dump(dir string, all bool) error {
+	var (
+		cols []dumpedCollection
+		err  error
+	)
+
+	if all {
+		cols, err = d.listCollections()
+	} else {
+		cols, err = d.seedCollections()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, col := range cols {
+		if err := d.dumpCollection(filepath.Join(dir, col.Name+".jsonl"), col.Name); err != nil {
+			return fmt.Errorf("dumping %s failed: %s", col.Name, err)
+		}
+	}
+
+	manifest, err := json.MarshalIndent(cols, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, dumpManifest), manifest, 0644)
+}
+
+// Restore loads a dump written by Dump, creating the missing collections.
+// Documents are inserted by batches, replacing the existing ones having the same key.
+func (d *ArangoDBManager) Restore(dir string) error {
+	cols, err := readManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	if d.dryRun != nil {
+		return d.planRestore(dir, cols)
+	}
+
+	for _, col := range cols {
+		_, err := d.db.Run(&arangolite.CreateCollection{
+			Name: col.Name,
+			Type: col.Type,
+		})
+		if err != nil && !strings.Contains(err.Error(), "duplicate name") {
+			return err
+		}
+
+		if err := d.restoreCollection(filepath.Join(dir, col.Name+".jsonl"), col.Name); err != nil {
+			return fmt.Errorf("restoring %s failed: %s", col.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// dumpCollection streams the documents of a collection to a file through a single cursor.
+func (d *ArangoDBManager) dumpCollection(path, colName string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+
+	q := arangolite.NewQuery(`
+		FOR x IN @@colName
+		RETURN UNSET(x,"_id","_rev")
+	`).Bind("@colName", colName)
+
+	err = d.runCursor(q, dumpBatchSize, func(page []byte) error {
+		docs := []json.RawMessage{}
+
+		if err := json.Unmarshal(page, &docs); err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			w.Write(doc)
+			if err := w.WriteByte('\n'); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func (d *ArangoDBManager) restoreCollection(path, colName string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	batch := make([]json.RawMessage, 0, dumpBatchSize)
+
+	insert := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		q := arangolite.NewQuery(`
+			FOR x IN @docs
+			UPSERT { '_key': x._key }
+			INSERT x
+			REPLACE x IN @@colName
+		`).Bind("docs", batch).Bind("@colName", colName)
+
+		if _, err := d.db.Run(q); err != nil {
+			return err
+		}
+
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			batch = append(batch, json.RawMessage(line))
+		}
+
+		if len(batch) == dumpBatchSize {
+			if err := insert(); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return insert()
+}
+
+func readManifest(dir string) ([]dumpedCollection, error) {
+	manifest, err := ioutil.ReadFile(filepath.Join(dir, dumpManifest))
+	if err != nil {
+		return nil, err
+	}
+
+	cols := []dumpedCollection{}
+
+	if err := json.Unmarshal(manifest, &cols); err != nil {
+		return nil, err
+	}
+
+	return cols, nil
+}
+
+// seedCollections returns the collections defined by the local seed.
+func (d *ArangoDBManager) seedCollections() ([]dumpedCollection, error) {
+	local := reflect.ValueOf(d.localSeed)
+
+	if local.Kind() != reflect.Ptr {
+		return nil, errors.New("invalid seed type: not a pointer")
+	}
+
+	local = local.Elem()
+
+	if local.Kind() != reflect.Struct {
+		return nil, errors.New("invalid seed type: not a struct")
+	}
+
+	cols := []dumpedCollection{}
+
+	for i := 0; i < local.NumField(); i++ {
+		field := local.Field(i)
+
+		if field.Kind() != reflect.Slice {
+			continue
+		}
+
+		arrayElem := field.Type().Elem()
+
+		if arrayElem.Kind() != reflect.Struct {
+			return nil, errors.New("invalid seed field type: not a struct")
+		}
+
+		colName := collectionName(local.Type().Field(i), d.naming)
+		colType := colTypeDoc
+
+		if _, ok := arrayElem.FieldByName("From"); ok {
+			colType = colTypeEdge
+		}
+
+		cols = append(cols, dumpedCollection{Name: colName, Type: colType})
+	}
+
+	return cols, nil
+}
+
+// listCollections returns all the non system collections of the database.
+func (d *ArangoDBManager) listCollections() ([]dumpedCollection, error) {
+	r, err := d.db.Run(&listCollections{})
+	if err != nil {
+		return nil, err
+	}
+
+	cols := []dumpedCollection{}
+
+	if err := json.Unmarshal(r, &cols); err != nil {
+		return nil, err
+	}
+
+	return cols, nil
+}
+
+type listCollections struct{}
+
+func (r *listCollections) Description() string { return "LIST COLLECTIONS" }
+func (r *listCollections) Generate() []byte    { return nil }
+func (r *listCollections) Method() string      { return "GET" }
+func (r *listCollections) Path() string        { return "/_api/collection?excludeSystem=true" }
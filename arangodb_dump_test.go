@@ -0,0 +1,48 @@
+package snakepit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRestoreDryRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snakepit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		dumpManifest:  `[{"name":"users","type":2}]`,
+		"users.jsonl": "{\"_key\":\"a\"}\n{\"_key\":\"b\"}\n\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cols, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &bytes.Buffer{}
+	d := NewArangoDBManager(&testSeed{}, &testSeed{}).DryRun(plan)
+	// A database planned to be dropped has no collection to query.
+	d.dropped = true
+
+	if err := d.planRestore(dir, cols); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"create collection users", "restore users: 2 documents"} {
+		if !strings.Contains(plan.String(), line) {
+			t.Errorf("plan %q misses %q", plan.String(), line)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package snakepit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var fixtureExts = []string{".json", ".yaml", ".yml"}
+
+// LoadSeedFiles populates the local seed from a directory of fixture files.
+// Each seed collection is read from a file named after its base name, the
+// lowerCamelCase `collection` tag or field name, for example "users.yaml" or
+// "users.json", so that the fixtures are shared by all the naming strategies and
+// tenants. A file named after the full collection name, such as "acme_users.yaml",
+// takes precedence. The documents are decoded using their json tags, and the
+// _from/_to references to a base name, such as "users/alice", are rewritten to
+// the full collection name. Collections without fixture file keep their current value.
+func (d *ArangoDBManager) LoadSeedFiles(dir string) error {
+	local := reflect.ValueOf(d.localSeed)
+
+	if local.Kind() != reflect.Ptr {
+		return errors.New("invalid seed type: not a pointer")
+	}
+
+	local = local.Elem()
+
+	if local.Kind() != reflect.Struct {
+		return errors.New("invalid seed type: not a struct")
+	}
+
+	names := map[string]string{}
+
+	for i := 0; i < local.NumField(); i++ {
+		if local.Field(i).Kind() == reflect.Slice {
+			names[collectionName(local.Type().Field(i), nil)] = collectionName(local.Type().Field(i), d.naming)
+		}
+	}
+
+	for i := 0; i < local.NumField(); i++ {
+		field := local.Field(i)
+
+		if field.Kind() != reflect.Slice {
+			continue
+		}
+
+		if field.Type().Elem().Kind() != reflect.Struct {
+			return errors.New("invalid seed field type: not a struct")
+		}
+
+		path, buf, err := readFixture(dir, collectionName(local.Type().Field(i), d.naming), collectionName(local.Type().Field(i), nil))
+		if err != nil {
+			return err
+		}
+		if buf == nil {
+			continue
+		}
+
+		if buf, err = rewriteEdgeRefs(buf, names); err != nil {
+			return fmt.Errorf("invalid fixture file %s: %s", path, err)
+		}
+
+		slice := reflect.New(field.Type())
+
+		if err := json.Unmarshal(buf, slice.Interface()); err != nil {
+			return fmt.Errorf("invalid fixture file %s: %s", path, err)
+		}
+
+		field.Set(slice.Elem())
+	}
+
+	return nil
+}
+
+// readFixture returns the path and JSON content of the first fixture file found
+// for the given names, or a nil content if there is none.
+func readFixture(dir string, names ...string) (string, []byte, error) {
+	for _, name := range names {
+		for _, ext := range fixtureExts {
+			path := filepath.Join(dir, name+ext)
+
+			buf, err := ioutil.ReadFile(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return "", nil, err
+			}
+
+			if ext != ".json" {
+				if buf, err = yamlToJSON(buf); err != nil {
+					return "", nil, fmt.Errorf("invalid fixture file %s: %s", path, err)
+				}
+			}
+
+			return path, buf, nil
+		}
+	}
+
+	return "", nil, nil
+}
+
+// rewriteEdgeRefs replaces the base collection names of the _from and _to
+// references of the fixture documents with the full collection names.
+func rewriteEdgeRefs(buf []byte, names map[string]string) ([]byte, error) {
+	docs := []map[string]interface{}{}
+
+	// The numbers are kept as is, large integer keys included.
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+
+	if err := dec.Decode(&docs); err != nil {
+		return nil, err
+	}
+
+	for _, doc := range docs {
+		for _, attr := range []string{"_from", "_to"} {
+			ref, ok := doc[attr].(string)
+			if !ok {
+				continue
+			}
+
+			parts := strings.SplitN(ref, "/", 2)
+			if name, ok := names[parts[0]]; ok && len(parts) == 2 {
+				doc[attr] = name + "/" + parts[1]
+			}
+		}
+	}
+
+	return json.Marshal(docs)
+}
+
+// yamlToJSON converts a YAML document to JSON so that the seed json tags apply.
+func yamlToJSON(buf []byte) ([]byte, error) {
+	var obj interface{}
+
+	if err := yaml.Unmarshal(buf, &obj); err != nil {
+		return nil, err
+	}
+
+	obj, err := convertYAML(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(obj)
+}
+
+func convertYAML(obj interface{}) (interface{}, error) {
+	switch o := obj.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(o))
+		for k, v := range o {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid non string key: %v", k)
+			}
+
+			val, err := convertYAML(v)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = val
+		}
+		return m, nil
+	case []interface{}:
+		for i, v := range o {
+			val, err := convertYAML(v)
+			if err != nil {
+				return nil, err
+			}
+			o[i] = val
+		}
+		return o, nil
+	}
+
+	return obj, nil
+}
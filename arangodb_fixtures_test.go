@@ -0,0 +1,68 @@
+package snakepit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSeedFilesPrefixed(t *testing.T) {
+	type user struct {
+		Key  string `json:"_key"`
+		Name string `json:"name"`
+	}
+	type friendship struct {
+		Key  string `json:"_key"`
+		From string `json:"_from"`
+		To   string `json:"_to"`
+	}
+	type seed struct {
+		Users       []user
+		Friendships []friendship `collection:"friendships"`
+	}
+
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"users.yaml":            "- _key: alice\n  name: Alice\n- _key: bob\n  name: Bob\n",
+		"friendships.json":      `[{"_key": "ab", "_from": "users/alice", "_to": "users/bob"}]`,
+		"acme_friendships.json": `[{"_key": "ab", "_from": "acme_users/alice", "_to": "others/bob"}]`,
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	local := &seed{}
+	d := NewArangoDBManager(local, &seed{}).Naming(Prefixed("tenant_", CamelCase))
+
+	if err := d.LoadSeedFiles(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(local.Users) != 2 || local.Users[1].Name != "Bob" {
+		t.Errorf("got users %+v, want the shared users.yaml ones", local.Users)
+	}
+
+	if len(local.Friendships) != 1 || local.Friendships[0].From != "tenant_users/alice" || local.Friendships[0].To != "tenant_users/bob" {
+		t.Errorf("got friendships %+v, want the references rewritten", local.Friendships)
+	}
+
+	local = &seed{}
+	d = NewArangoDBManager(local, &seed{}).Naming(Prefixed("acme_", CamelCase))
+
+	if err := d.LoadSeedFiles(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(local.Friendships) != 1 || local.Friendships[0].From != "acme_users/alice" || local.Friendships[0].To != "others/bob" {
+		t.Errorf("got friendships %+v, want the acme_friendships.json ones", local.Friendships)
+	}
+}
@@ -0,0 +1,262 @@
+package snakepit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/solher/arangolite"
+)
+
+type graph struct {
+	Name              string           `json:"_key"`
+	EdgeDefinitions   []edgeDefinition `json:"edgeDefinitions"`
+	OrphanCollections []string         `json:"orphanCollections"`
+}
+
+type edgeDefinition struct {
+	Collection string   `json:"collection"`
+	From       []string `json:"from"`
+	To         []string `json:"to"`
+}
+
+func (e *edgeDefinition) matches(o *edgeDefinition) bool {
+	return e.Collection == o.Collection &&
+		strings.Join(e.From, ",") == strings.Join(o.From, ",") &&
+		strings.Join(e.To, ",") == strings.Join(o.To, ",")
+}
+
+type graphOp struct {
+	description string
+	request     arangolite.Runnable
+}
+
+// parseGraphs reads the named graphs declared on the edge collections of a seed.
+// An edge collection joins one or more graphs by tagging its seed field, for example
+// `graph:"social" from:"users" to:"users,groups"`. The vertex collection names
+// go through the naming strategy, like the seed field ones.
+func parseGraphs(seed reflect.Value, naming NamingStrategy) ([]graph, error) {
+	graphs := []graph{}
+	indexes := map[string]int{}
+
+	for i := 0; i < seed.NumField(); i++ {
+		field := seed.Type().Field(i)
+
+		tag := field.Tag.Get("graph")
+		if tag == "" || field.Type.Kind() != reflect.Slice {
+			continue
+		}
+
+		if _, ok := field.Type.Elem().FieldByName("From"); !ok {
+			return nil, fmt.Errorf("invalid graph declaration on %s: not an edge collection", field.Name)
+		}
+
+		from, to := field.Tag.Get("from"), field.Tag.Get("to")
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("invalid graph declaration on %s: missing from or to vertex collections", field.Name)
+		}
+
+		def := edgeDefinition{Collection: collectionName(field, naming)}
+
+		for _, name := range strings.Split(from, ",") {
+			def.From = append(def.From, applyNaming(naming, name))
+		}
+		for _, name := range strings.Split(to, ",") {
+			def.To = append(def.To, applyNaming(naming, name))
+		}
+		sort.Strings(def.From)
+		sort.Strings(def.To)
+
+		for _, name := range strings.Split(tag, ",") {
+			j, ok := indexes[name]
+			if !ok {
+				j = len(graphs)
+				indexes[name] = j
+				graphs = append(graphs, graph{Name: name, OrphanCollections: []string{}})
+			}
+
+			graphs[j].EdgeDefinitions = append(graphs[j].EdgeDefinitions, def)
+		}
+	}
+
+	return graphs, nil
+}
+
+// listGraphs returns the named graphs of the database.
+func (d *ArangoDBManager) listGraphs() ([]graph, error) {
+	r, err := d.db.Run(&listGraphs{})
+	if err != nil {
+		return nil, err
+	}
+
+	res := struct {
+		Graphs []graph `json:"graphs"`
+	}{}
+
+	if err := json.Unmarshal(r, &res); err != nil {
+		return nil, err
+	}
+
+	for _, g := range res.Graphs {
+		for i := range g.EdgeDefinitions {
+			sort.Strings(g.EdgeDefinitions[i].From)
+			sort.Strings(g.EdgeDefinitions[i].To)
+		}
+	}
+
+	return res.Graphs, nil
+}
+
+// graphOps returns the requests creating or updating the existing graphs to match the declared ones.
+func graphOps(declared, existing []graph) []graphOp {
+	ops := []graphOp{}
+	byName := make(map[string]*graph, len(existing))
+
+	for i := range existing {
+		byName[existing[i].Name] = &existing[i]
+	}
+
+	for i := range declared {
+		g := declared[i]
+
+		e, ok := byName[g.Name]
+		if !ok {
+			ops = append(ops, graphOp{
+				description: fmt.Sprintf("create graph %s", g.Name),
+				request:     &createGraph{Graph: g},
+			})
+			continue
+		}
+
+		for j := range g.EdgeDefinitions {
+			def := g.EdgeDefinitions[j]
+
+			var current *edgeDefinition
+			for k := range e.EdgeDefinitions {
+				if e.EdgeDefinitions[k].Collection == def.Collection {
+					current = &e.EdgeDefinitions[k]
+					break
+				}
+			}
+
+			switch {
+			case current == nil:
+				ops = append(ops, graphOp{
+					description: fmt.Sprintf("add edge definition %s to graph %s", def.Collection, g.Name),
+					request:     &addEdgeDefinition{GraphName: g.Name, Definition: def},
+				})
+			case !current.matches(&def):
+				ops = append(ops, graphOp{
+					description: fmt.Sprintf("replace edge definition %s of graph %s", def.Collection, g.Name),
+					request:     &replaceEdgeDefinition{GraphName: g.Name, Definition: def},
+				})
+			}
+		}
+
+		for j := range e.EdgeDefinitions {
+			found := false
+			for k := range g.EdgeDefinitions {
+				if g.EdgeDefinitions[k].Collection == e.EdgeDefinitions[j].Collection {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				ops = append(ops, graphOp{
+					description: fmt.Sprintf("remove edge definition %s from graph %s", e.EdgeDefinitions[j].Collection, g.Name),
+					request:     &removeEdgeDefinition{GraphName: g.Name, Collection: e.EdgeDefinitions[j].Collection},
+				})
+			}
+		}
+	}
+
+	return ops
+}
+
+func (d *ArangoDBManager) syncGraphs(declared []graph) error {
+	if len(declared) == 0 {
+		return nil
+	}
+
+	existing, err := d.listGraphs()
+	if err != nil {
+		return err
+	}
+
+	for _, op := range graphOps(declared, existing) {
+		if _, err := d.db.Run(op.request); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type listGraphs struct{}
+
+func (r *listGraphs) Description() string { return "LIST GRAPHS" }
+func (r *listGraphs) Generate() []byte    { return nil }
+func (r *listGraphs) Method() string      { return "GET" }
+func (r *listGraphs) Path() string        { return "/_api/gharial" }
+
+type createGraph struct {
+	Graph graph
+}
+
+func (r *createGraph) Description() string { return "CREATE GRAPH" }
+func (r *createGraph) Method() string      { return "POST" }
+func (r *createGraph) Path() string        { return "/_api/gharial" }
+func (r *createGraph) Generate() []byte {
+	m, _ := json.Marshal(map[string]interface{}{
+		"name":              r.Graph.Name,
+		"edgeDefinitions":   r.Graph.EdgeDefinitions,
+		"orphanCollections": r.Graph.OrphanCollections,
+	})
+	return m
+}
+
+type addEdgeDefinition struct {
+	GraphName  string
+	Definition edgeDefinition
+}
+
+func (r *addEdgeDefinition) Description() string { return "ADD EDGE DEFINITION" }
+func (r *addEdgeDefinition) Method() string      { return "POST" }
+func (r *addEdgeDefinition) Path() string {
+	return "/_api/gharial/" + url.PathEscape(r.GraphName) + "/edge"
+}
+func (r *addEdgeDefinition) Generate() []byte {
+	m, _ := json.Marshal(r.Definition)
+	return m
+}
+
+type replaceEdgeDefinition struct {
+	GraphName  string
+	Definition edgeDefinition
+}
+
+func (r *replaceEdgeDefinition) Description() string { return "REPLACE EDGE DEFINITION" }
+func (r *replaceEdgeDefinition) Method() string      { return "PUT" }
+func (r *replaceEdgeDefinition) Path() string {
+	return "/_api/gharial/" + url.PathEscape(r.GraphName) + "/edge/" + url.PathEscape(r.Definition.Collection)
+}
+func (r *replaceEdgeDefinition) Generate() []byte {
+	m, _ := json.Marshal(r.Definition)
+	return m
+}
+
+type removeEdgeDefinition struct {
+	GraphName  string
+	Collection string
+}
+
+func (r *removeEdgeDefinition) Description() string { return "REMOVE EDGE DEFINITION" }
+func (r *removeEdgeDefinition) Generate() []byte    { return nil }
+func (r *removeEdgeDefinition) Method() string      { return "DELETE" }
+func (r *removeEdgeDefinition) Path() string {
+	return "/_api/gharial/" + url.PathEscape(r.GraphName) + "/edge/" + url.PathEscape(r.Collection)
+}
@@ -0,0 +1,239 @@
+package snakepit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	indexHash     = "hash"
+	indexSkipList = "skiplist"
+	indexFullText = "fulltext"
+	indexGeo      = "geo"
+
+	// defaultMinLength is the minLength ArangoDB gives the fulltext indexes declaring none.
+	defaultMinLength = 2
+)
+
+type index struct {
+	ID        string   `json:"id,omitempty"`
+	Type      string   `json:"type"`
+	Fields    []string `json:"fields"`
+	Unique    bool     `json:"unique,omitempty"`
+	Sparse    bool     `json:"sparse,omitempty"`
+	GeoJSON   bool     `json:"geoJson,omitempty"`
+	MinLength int      `json:"minLength,omitempty"`
+}
+
+// matches reports whether two indexes are equivalent for ArangoDB.
+func (i *index) matches(o *index) bool {
+	if i.Type != o.Type || strings.Join(i.Fields, ",") != strings.Join(o.Fields, ",") {
+		return false
+	}
+
+	switch i.Type {
+	case indexHash, indexSkipList:
+		return i.Unique == o.Unique && i.Sparse == o.Sparse
+	case indexGeo:
+		return i.GeoJSON == o.GeoJSON
+	case indexFullText:
+		return i.MinLength == o.MinLength
+	}
+
+	return true
+}
+
+// parseIndexes reads the index declarations of a seed element type.
+// An index is declared by tagging a field, for example `index:"hash,unique,sparse"`,
+// `index:"skiplist"`, `index:"fulltext,minLength=3"` or `index:"geo,geoJson"`.
+func parseIndexes(elem reflect.Type) ([]index, error) {
+	indexes := []index{}
+
+	for i := 0; i < elem.NumField(); i++ {
+		field := elem.Field(i)
+
+		tag := field.Tag.Get("index")
+		if tag == "" {
+			continue
+		}
+
+		options := strings.Split(tag, ",")
+
+		idx := index{
+			Type:   options[0],
+			Fields: []string{jsonFieldName(field)},
+		}
+
+		switch idx.Type {
+		case indexHash, indexSkipList, indexGeo:
+		case indexFullText:
+			idx.MinLength = defaultMinLength
+		default:
+			return nil, fmt.Errorf("invalid index type on field %s: %s", field.Name, idx.Type)
+		}
+
+		for _, opt := range options[1:] {
+			switch {
+			case opt == "unique":
+				idx.Unique = true
+			case opt == "sparse":
+				idx.Sparse = true
+			case opt == "geoJson":
+				idx.GeoJSON = true
+			case strings.HasPrefix(opt, "minLength="):
+				minLength, err := strconv.Atoi(strings.TrimPrefix(opt, "minLength="))
+				if err != nil {
+					return nil, fmt.Errorf("invalid index minLength on field %s: %s", field.Name, opt)
+				}
+				idx.MinLength = minLength
+			default:
+				return nil, fmt.Errorf("invalid index option on field %s: %s", field.Name, opt)
+			}
+		}
+
+		indexes = append(indexes, idx)
+	}
+
+	return indexes, nil
+}
+
+// jsonFieldName returns the name under which a struct field is stored in the database.
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
+
+// syncIndexes creates the declared indexes of a collection and drops the
+// existing ones that are no longer declared.
+func (d *ArangoDBManager) syncIndexes(colName string, declared []index) error {
+	create, drop, err := d.diffIndexes(colName, declared)
+	if err != nil {
+		return err
+	}
+
+	for i := range drop {
+		if _, err := d.db.Run(&dropIndex{ID: drop[i].ID}); err != nil {
+			return err
+		}
+	}
+
+	for i := range create {
+		if _, err := d.db.Run(&ensureIndex{CollectionName: colName, Index: create[i]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diffIndexes compares the declared indexes of a collection with the existing ones.
+func (d *ArangoDBManager) diffIndexes(colName string, declared []index) (create, drop []index, err error) {
+	r, err := d.db.Run(&listIndexes{CollectionName: colName})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing := struct {
+		Indexes []index `json:"indexes"`
+	}{}
+
+	if err := json.Unmarshal(r, &existing); err != nil {
+		return nil, nil, err
+	}
+
+	create, drop = indexDiff(declared, existing.Indexes)
+
+	return create, drop, nil
+}
+
+// indexDiff returns the declared indexes missing from the existing ones, and the
+// existing ones no longer declared. The primary and edge indexes are ignored.
+func indexDiff(declared, existing []index) (create, drop []index) {
+	kept := []index{}
+
+	for _, e := range existing {
+		// ArangoDB reports geo indexes as "geo1" or "geo2".
+		if strings.HasPrefix(e.Type, indexGeo) {
+			e.Type = indexGeo
+		}
+
+		switch e.Type {
+		case indexHash, indexSkipList, indexFullText, indexGeo:
+		default:
+			continue
+		}
+
+		found := false
+		for i := range declared {
+			if declared[i].matches(&e) {
+				found = true
+				break
+			}
+		}
+
+		if found {
+			kept = append(kept, e)
+		} else {
+			drop = append(drop, e)
+		}
+	}
+
+	for i := range declared {
+		found := false
+		for j := range kept {
+			if declared[i].matches(&kept[j]) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			create = append(create, declared[i])
+		}
+	}
+
+	return create, drop
+}
+
+type listIndexes struct {
+	CollectionName string
+}
+
+func (r *listIndexes) Description() string { return "LIST INDEXES" }
+func (r *listIndexes) Generate() []byte    { return nil }
+func (r *listIndexes) Method() string      { return "GET" }
+func (r *listIndexes) Path() string {
+	return "/_api/index?collection=" + url.QueryEscape(r.CollectionName)
+}
+
+type ensureIndex struct {
+	CollectionName string
+	Index          index
+}
+
+func (r *ensureIndex) Description() string { return "ENSURE INDEX" }
+func (r *ensureIndex) Method() string      { return "POST" }
+func (r *ensureIndex) Path() string {
+	return "/_api/index?collection=" + url.QueryEscape(r.CollectionName)
+}
+func (r *ensureIndex) Generate() []byte {
+	m, _ := json.Marshal(r.Index)
+	return m
+}
+
+type dropIndex struct {
+	ID string
+}
+
+func (r *dropIndex) Description() string { return "DROP INDEX" }
+func (r *dropIndex) Generate() []byte    { return nil }
+func (r *dropIndex) Method() string      { return "DELETE" }
+func (r *dropIndex) Path() string        { return "/_api/index/" + r.ID }
@@ -0,0 +1,106 @@
+package snakepit
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// serverIndexes is shaped like the GET /_api/index response of ArangoDB.
+const serverIndexes = `{
+	"indexes": [
+		{"id": "places/0", "type": "primary", "fields": ["_key"], "unique": true, "sparse": false, "selectivityEstimate": 1},
+		{"id": "places/11", "type": "hash", "fields": ["code"], "unique": true, "sparse": false, "selectivityEstimate": 1},
+		{"id": "places/12", "type": "fulltext", "fields": ["name"], "unique": false, "sparse": true, "minLength": 2},
+		{"id": "places/13", "type": "geo1", "fields": ["location"], "unique": false, "sparse": true, "geoJson": true, "constraint": false},
+		{"id": "places/14", "type": "skiplist", "fields": ["population"], "unique": false, "sparse": false}
+	]
+}`
+
+func TestIndexDiff(t *testing.T) {
+	type place struct {
+		Code       string     `json:"code" index:"hash,unique"`
+		Name       string     `json:"name" index:"fulltext"`
+		Location   [2]float64 `json:"location" index:"geo,geoJson"`
+		Population int        `json:"population" index:"skiplist,sparse"`
+	}
+
+	existing := struct {
+		Indexes []index `json:"indexes"`
+	}{}
+
+	if err := json.Unmarshal([]byte(serverIndexes), &existing); err != nil {
+		t.Fatal(err)
+	}
+
+	declared, err := parseIndexes(reflect.TypeOf(place{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create, drop := indexDiff(declared, existing.Indexes)
+
+	if len(create) != 1 || create[0].Type != indexSkipList || !create[0].Sparse {
+		t.Errorf("got created %+v, want the sparse skiplist", create)
+	}
+
+	if len(drop) != 1 || drop[0].ID != "places/14" {
+		t.Errorf("got dropped %+v, want places/14", drop)
+	}
+}
+
+func TestIndexMatches(t *testing.T) {
+	cases := []struct {
+		declared, existing index
+		matches            bool
+	}{
+		{
+			declared: index{Type: indexFullText, Fields: []string{"name"}, MinLength: defaultMinLength},
+			existing: index{Type: indexFullText, Fields: []string{"name"}, MinLength: 2},
+			matches:  true,
+		},
+		{
+			declared: index{Type: indexFullText, Fields: []string{"name"}, MinLength: 3},
+			existing: index{Type: indexFullText, Fields: []string{"name"}, MinLength: 2},
+		},
+		{
+			declared: index{Type: indexHash, Fields: []string{"code"}, Unique: true},
+			existing: index{Type: indexHash, Fields: []string{"code"}},
+		},
+		{
+			declared: index{Type: indexHash, Fields: []string{"code"}},
+			existing: index{Type: indexSkipList, Fields: []string{"code"}},
+		},
+		{
+			declared: index{Type: indexGeo, Fields: []string{"location"}, GeoJSON: true},
+			existing: index{Type: indexGeo, Fields: []string{"location"}, GeoJSON: true},
+			matches:  true,
+		},
+		{
+			declared: index{Type: indexGeo, Fields: []string{"lat", "lng"}},
+			existing: index{Type: indexGeo, Fields: []string{"lng", "lat"}},
+		},
+	}
+
+	for _, c := range cases {
+		if matches := c.declared.matches(&c.existing); matches != c.matches {
+			t.Errorf("%+v against %+v: got %t, want %t", c.declared, c.existing, matches, c.matches)
+		}
+	}
+}
+
+func TestParseIndexesFullTextDefaultMinLength(t *testing.T) {
+	type doc struct {
+		Name string `json:"name" index:"fulltext"`
+		Bio  string `json:"bio" index:"fulltext,minLength=4"`
+	}
+
+	indexes, err := parseIndexes(reflect.TypeOf(doc{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if indexes[0].MinLength != defaultMinLength || indexes[1].MinLength != 4 {
+		t.Errorf("got minLengths %d and %d, want %d and 4", indexes[0].MinLength, indexes[1].MinLength, defaultMinLength)
+	}
+}
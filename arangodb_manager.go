@@ -1,13 +1,15 @@
 package snakepit
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/solher/arangolite"
+	"golang.org/x/net/context"
 )
 
 const (
@@ -20,6 +22,15 @@ type Seed interface{}
 type ArangoDBManager struct {
 	db                     *arangolite.DB
 	localSeed, distantSeed Seed
+	migrations             []Migration
+	dryRun                 io.Writer
+	dropped                bool
+	transactional          bool
+	batchSize              int
+	progress               io.Writer
+	metrics                *Metrics
+	naming                 NamingStrategy
+	loggerOptions          [3]bool
 	URL, Name              string
 	User, UserPassword     string
 }
@@ -44,19 +55,53 @@ func (d *ArangoDBManager) Connect(url, name, user, userPassword string) *ArangoD
 }
 
 func (d *ArangoDBManager) LoggerOptions(enabled, printQuery, printResult bool) *ArangoDBManager {
+	d.loggerOptions = [3]bool{enabled, printQuery, printResult}
 	d.db.LoggerOptions(enabled, printQuery, printResult)
 	return d
 }
 
+// adminDB returns a dedicated connection to the _system database, so that the
+// administrative operations never switch the database or user of the app connection.
+func (d *ArangoDBManager) adminDB(rootUser, rootPassword string) *arangolite.DB {
+	return arangolite.New().
+		LoggerOptions(d.loggerOptions[0], d.loggerOptions[1], d.loggerOptions[2]).
+		Connect(d.URL, "_system", rootUser, rootPassword)
+}
+
+// Naming sets the strategy deriving the collection names from the seed fields.
+func (d *ArangoDBManager) Naming(strategy NamingStrategy) *ArangoDBManager {
+	d.naming = strategy
+	return d
+}
+
 func (d *ArangoDBManager) Run(q arangolite.Runnable) ([]byte, error) {
-	return d.db.Run(q)
+	start := time.Now()
+
+	r, err := d.db.Run(q)
+	d.metrics.ObserveQuery(q.Description(), start, err)
+
+	return r, err
+}
+
+// Metrics sets the registry where the durations and failures of Run are recorded.
+func (d *ArangoDBManager) Metrics(m *Metrics) *ArangoDBManager {
+	d.metrics = m
+	return d
+}
+
+// HealthCheck verifies that the database is reachable with the app credentials.
+// It can be registered as is in a Health registry.
+func (d *ArangoDBManager) HealthCheck(ctx context.Context) error {
+	_, err := d.db.Run(arangolite.NewQuery(`RETURN 1`))
+	return err
 }
 
 func (d *ArangoDBManager) Create(rootUser, rootPassword string) error {
-	d.db.SwitchDatabase("_system").SwitchUser(rootUser, rootPassword)
-	defer func() { d.db.SwitchDatabase(d.Name).SwitchUser(d.User, d.UserPassword) }()
+	if d.dryRun != nil {
+		return d.planCreate()
+	}
 
-	_, err := d.db.Run(&arangolite.CreateDatabase{
+	_, err := d.adminDB(rootUser, rootPassword).Run(&arangolite.CreateDatabase{
 		Name: d.Name,
 		Users: []map[string]interface{}{
 			{"username": rootUser, "passwd": rootPassword},
@@ -72,6 +117,10 @@ func (d *ArangoDBManager) Create(rootUser, rootPassword string) error {
 }
 
 func (d *ArangoDBManager) Migrate() error {
+	if d.dryRun != nil {
+		return d.planMigrate()
+	}
+
 	local := reflect.ValueOf(d.localSeed)
 
 	if local.Kind() != reflect.Ptr {
@@ -84,6 +133,11 @@ func (d *ArangoDBManager) Migrate() error {
 		return errors.New("invalid seed type: not a struct")
 	}
 
+	graphs, err := parseGraphs(local, d.naming)
+	if err != nil {
+		return err
+	}
+
 	for i := 0; i < local.NumField(); i++ {
 		field := local.Field(i)
 
@@ -97,8 +151,7 @@ func (d *ArangoDBManager) Migrate() error {
 			return errors.New("invalid seed field type: not a struct")
 		}
 
-		colName := local.Type().Field(i).Name
-		colName = strings.ToLower(colName[0:1]) + colName[1:]
+		colName := collectionName(local.Type().Field(i), d.naming)
 		colType := colTypeDoc
 
 		// if _, ok := local.Field(i).Type().Elem().FieldByName("Edge"); ok {
@@ -106,23 +159,37 @@ func (d *ArangoDBManager) Migrate() error {
 			colType = colTypeEdge
 		}
 
-		_, err := d.db.Run(&arangolite.CreateCollection{
+		indexes, err := parseIndexes(arrayElem)
+		if err != nil {
+			return err
+		}
+
+		_, err = d.db.Run(&arangolite.CreateCollection{
 			Name: colName,
 			Type: colType,
 		})
 		if err != nil && !strings.Contains(err.Error(), "duplicate name") {
 			return err
 		}
+
+		if err := d.syncIndexes(colName, indexes); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	if err := d.syncGraphs(graphs); err != nil {
+		return err
+	}
+
+	return d.runMigrations()
 }
 
 func (d *ArangoDBManager) Drop(rootUser, rootPassword string) error {
-	d.db.SwitchDatabase("_system").SwitchUser(rootUser, rootPassword)
-	defer func() { d.db.SwitchDatabase(d.Name).SwitchUser(d.User, d.UserPassword) }()
+	if d.dryRun != nil {
+		return d.planDrop()
+	}
 
-	_, err := d.db.Run(&arangolite.DropDatabase{Name: d.Name})
+	_, err := d.adminDB(rootUser, rootPassword).Run(&arangolite.DropDatabase{Name: d.Name})
 	if err != nil {
 		return err
 	}
@@ -145,6 +212,8 @@ func (d *ArangoDBManager) LoadDistantSeed() error {
 		return errors.New("invalid seed type: not a struct")
 	}
 
+	drift := &SeedDriftError{}
+
 	for i := 0; i < local.NumField(); i++ {
 		localField := local.Field(i)
 		distantField := distant.Field(i)
@@ -159,46 +228,62 @@ func (d *ArangoDBManager) LoadDistantSeed() error {
 			return errors.New("invalid seed field type: not a struct")
 		}
 
-		colName := local.Type().Field(i).Name
-		colName = strings.ToLower(colName[0:1]) + colName[1:]
+		colName := collectionName(local.Type().Field(i), d.naming)
 
-		var q *arangolite.Query
+		aql := `
+			FOR x IN @@colName
+			LET i = UNSET(x,"_id","_rev")
+				FOR y IN @seed != null ? @seed : []
+				LET j = UNSET(y,"_id","_rev")
+				LET merged = MERGE(i,j)
+				FILTER MATCHES(i, merged)
+				RETURN DISTINCT x
+		`
 
 		if local.Type().Field(i).Tag.Get("check") == "keyOnly" {
-			q = arangolite.NewQuery(`
+			aql = `
 				FOR x IN @@colName
 					FOR y IN @seed != null ? @seed : []
 					FILTER x._key == y._key
 					RETURN DISTINCT x
-			`).Bind("seed", localField.Interface()).Bind("@colName", colName)
-		} else {
-			q = arangolite.NewQuery(`
-				FOR x IN @@colName
-				LET i = UNSET(x,"_id","_rev")
-					FOR y IN @seed != null ? @seed : []
-					LET j = UNSET(y,"_id","_rev")
-					LET merged = MERGE(i,j)
-					FILTER MATCHES(i, merged)
-					RETURN DISTINCT x
-			`).Bind("seed", localField.Interface()).Bind("@colName", colName)
+			`
 		}
 
-		r, err := d.db.Run(q)
-		if err != nil {
-			return err
-		}
+		distantField.Set(reflect.MakeSlice(distantField.Type(), 0, localField.Len()))
 
-		json.Unmarshal(r, distantField.Addr().Interface())
+		// The local seed is sent by batches, and the matching documents read by pages.
+		for _, batch := range seedBatches(localField, d.seedBatchSize()) {
+			q := arangolite.NewQuery(aql).Bind("seed", batch).Bind("@colName", colName)
+
+			if err := d.runPaged(q, distantField.Addr()); err != nil {
+				return err
+			}
+		}
 
 		if distantField.Len() < localField.Len() {
-			return fmt.Errorf("seeds not synchronized: %s", colName)
+			keyOnly := local.Type().Field(i).Tag.Get("check") == "keyOnly"
+
+			colDrift, err := d.collectionDrift(colName, localField.Interface(), keyOnly)
+			if err != nil {
+				return err
+			}
+
+			drift.Collections = append(drift.Collections, *colDrift)
 		}
 	}
 
+	if len(drift.Collections) > 0 {
+		return drift
+	}
+
 	return nil
 }
 
 func (d *ArangoDBManager) SyncSeeds() error {
+	if d.dryRun != nil {
+		return d.planSeeds()
+	}
+
 	local := reflect.ValueOf(d.localSeed)
 
 	if local.Kind() != reflect.Ptr {
@@ -211,6 +296,16 @@ func (d *ArangoDBManager) SyncSeeds() error {
 		return errors.New("invalid seed type: not a struct")
 	}
 
+	if err := ValidateSeed(d.localSeed, d.naming); err != nil {
+		return err
+	}
+
+	if err := d.createSeedCol(); err != nil {
+		return err
+	}
+
+	steps := []seedStep{}
+
 	for i := 0; i < local.NumField(); i++ {
 		field := local.Field(i)
 
@@ -218,7 +313,7 @@ func (d *ArangoDBManager) SyncSeeds() error {
 			continue
 		}
 
-		if field.Len() == 0 {
+		if field.Len() == 0 && !hasTagOption(local.Type().Field(i).Tag.Get("seed"), "prune") {
 			continue
 		}
 
@@ -228,30 +323,112 @@ func (d *ArangoDBManager) SyncSeeds() error {
 			return errors.New("invalid seed field type: not a struct")
 		}
 
-		colName := local.Type().Field(i).Name
-		colName = strings.ToLower(colName[0:1]) + colName[1:]
+		colName := collectionName(local.Type().Field(i), d.naming)
 
-		var q *arangolite.Query
-
-		if local.Type().Field(i).Tag.Get("seed") == "forceUpdate" {
-			q = arangolite.NewQuery(`
-				FOR x IN @seed
-				FILTER x._key != "" && x._key != NULL
-				UPSERT { '_key': x._key }
-                INSERT x
-				REPLACE x IN @@colName
-			`).Bind("seed", field.Interface()).Bind("@colName", colName)
-		} else {
-			q = arangolite.NewQuery(`
+		aql := `
+			LET inserted = (
 				FOR x IN @seed
 				FILTER x._key != "" && x._key != NULL
-                INSERT x IN @@colName OPTIONS { ignoreErrors: true }
-			`).Bind("seed", field.Interface()).Bind("@colName", colName)
+				INSERT x IN @@colName OPTIONS { ignoreErrors: true }
+				RETURN NEW._key
+			)
+			UPSERT { '_key': @name }
+			INSERT { '_key': @name, 'keys': inserted }
+			UPDATE { 'keys': UNION_DISTINCT(OLD.keys, inserted) } IN @@seedCol
+		`
+
+		if hasTagOption(local.Type().Field(i).Tag.Get("seed"), "forceUpdate") {
+			aql = `
+				LET inserted = (
+					FOR x IN @seed
+					FILTER x._key != "" && x._key != NULL
+					UPSERT { '_key': x._key }
+					INSERT x
+					REPLACE x IN @@colName
+					RETURN OLD == NULL ? NEW._key : NULL
+				)
+				UPSERT { '_key': @name }
+				INSERT { '_key': @name, 'keys': REMOVE_VALUE(inserted, NULL) }
+				UPDATE { 'keys': UNION_DISTINCT(OLD.keys, REMOVE_VALUE(inserted, NULL)) } IN @@seedCol
+			`
+		}
+
+		for _, batch := range seedBatches(field, d.seedBatchSize()) {
+			steps = append(steps, seedStep{
+				Collection: colName,
+				Query:      aql,
+				BindVars: map[string]interface{}{
+					"seed":     batch,
+					"name":     colName,
+					"@colName": colName,
+					"@seedCol": d.seedCol(),
+				},
+				Batch: "seed",
+				Count: reflect.ValueOf(batch).Len(),
+				Total: field.Len(),
+			})
 		}
 
-		if _, err := d.db.Run(q); err != nil {
+		keys, err := seedKeys(field.Interface())
+		if err != nil {
 			return err
 		}
+
+		// The keys seeded before and no longer in the local seed are computed
+		// beforehand, so that only those are sent, by batches too.
+		stale, err := d.staleKeys(colName, keys)
+		if err != nil {
+			return err
+		}
+
+		for _, batch := range seedBatches(reflect.ValueOf(stale), d.seedBatchSize()) {
+			if hasTagOption(local.Type().Field(i).Tag.Get("seed"), "prune") {
+				steps = append(steps, seedStep{
+					Collection: colName,
+					Query: `
+						FOR key IN @keys
+						REMOVE key IN @@colName OPTIONS { ignoreErrors: true }
+					`,
+					BindVars: map[string]interface{}{"keys": batch, "@colName": colName},
+					Batch:    "keys",
+				})
+			}
+
+			// The stale keys are forgotten.
+			steps = append(steps, seedStep{
+				Collection: colName,
+				Query: `
+					LET seeded = DOCUMENT(CONCAT(@seedCol, "/", @name))
+					UPDATE seeded WITH { 'keys': MINUS(seeded.keys, @keys) } IN @@seedCol
+				`,
+				BindVars: map[string]interface{}{"name": colName, "keys": batch, "seedCol": d.seedCol(), "@seedCol": d.seedCol()},
+				Batch:    "keys",
+			})
+		}
+	}
+
+	if d.transactional {
+		if err := d.runSeedTransaction(steps); err != nil {
+			return err
+		}
+	} else {
+		done := map[string]int{}
+
+		for _, step := range steps {
+			q := arangolite.NewQuery(step.Query)
+			for name, value := range step.BindVars {
+				q.Bind(name, value)
+			}
+
+			if _, err := d.db.Run(q); err != nil {
+				return fmt.Errorf("seeding %s failed: %s", step.Collection, err)
+			}
+
+			if step.Count > 0 {
+				done[step.Collection] += step.Count
+				d.progressf("seeding %s: %d/%d", step.Collection, done[step.Collection], step.Total)
+			}
+		}
 	}
 
 	if err := d.LoadDistantSeed(); err != nil {
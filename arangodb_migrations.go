@@ -0,0 +1,209 @@
+package snakepit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/solher/arangolite"
+)
+
+const migrationColName = "snakepitMigrations"
+
+// Migration defines a versioned schema change applied by a DatabaseManager.
+// Up and Down receive the manager running them, which an ArangoDB specific
+// migration can assert to *ArangoDBManager.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(m DatabaseManager) error
+	Down    func(m DatabaseManager) error
+}
+
+type appliedMigration struct {
+	Key       string    `json:"_key"`
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+// RegisterMigrations adds migrations to the manager registry.
+// They are applied by ascending version, whatever the registration order.
+func (d *ArangoDBManager) RegisterMigrations(migrations ...Migration) *ArangoDBManager {
+	d.migrations = append(d.migrations, migrations...)
+	return d
+}
+
+// Rollback reverts the last n applied migrations, in reverse order.
+func (d *ArangoDBManager) Rollback(n int) error {
+	if d.dryRun != nil {
+		return d.planRollback(n)
+	}
+
+	if err := d.createMigrationCol(); err != nil {
+		return err
+	}
+
+	registry, err := migrationRegistry(d.migrations)
+	if err != nil {
+		return err
+	}
+
+	applied, err := d.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for i := len(applied) - 1; i >= 0 && n > 0; i, n = i-1, n-1 {
+		m, ok := registry[applied[i].Version]
+		if !ok {
+			return fmt.Errorf("unknown applied migration: %d", applied[i].Version)
+		}
+
+		if m.Down == nil {
+			return fmt.Errorf("irreversible migration: %d", m.Version)
+		}
+
+		if err := m.Down(d); err != nil {
+			return fmt.Errorf("migration %d rollback failed: %s", m.Version, err)
+		}
+
+		q := arangolite.NewQuery(`
+			REMOVE @key IN @@colName
+		`).Bind("key", applied[i].Key).Bind("@colName", d.migrationCol())
+
+		if _, err := d.db.Run(q); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *ArangoDBManager) runMigrations() error {
+	if err := d.createMigrationCol(); err != nil {
+		return err
+	}
+
+	applied, err := d.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	pending, err := pendingMigrations(d.migrations, applied)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if err := m.Up(d); err != nil {
+			return fmt.Errorf("migration %d failed: %s", m.Version, err)
+		}
+
+		q := arangolite.NewQuery(`
+			INSERT @migration IN @@colName
+		`).Bind("migration", appliedMigration{
+			Key:       strconv.Itoa(m.Version),
+			Version:   m.Version,
+			Name:      m.Name,
+			AppliedAt: time.Now().UTC(),
+		}).Bind("@colName", d.migrationCol())
+
+		if _, err := d.db.Run(q); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pendingMigrations returns the registered migrations not yet applied, by ascending version.
+func pendingMigrations(migrations []Migration, applied []appliedMigration) ([]Migration, error) {
+	registry, err := migrationRegistry(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[int]bool, len(applied))
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	versions := make([]int, 0, len(registry))
+	for v := range registry {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+
+	pending := []Migration{}
+
+	for _, v := range versions {
+		if done[v] {
+			continue
+		}
+
+		m := registry[v]
+
+		if m.Up == nil {
+			return nil, fmt.Errorf("invalid migration %d: nil up func", m.Version)
+		}
+
+		pending = append(pending, m)
+	}
+
+	return pending, nil
+}
+
+func (d *ArangoDBManager) migrationCol() string {
+	return applyNaming(d.naming, migrationColName)
+}
+
+func (d *ArangoDBManager) createMigrationCol() error {
+	_, err := d.db.Run(&arangolite.CreateCollection{
+		Name: d.migrationCol(),
+		Type: colTypeDoc,
+	})
+	if err != nil && !strings.Contains(err.Error(), "duplicate name") {
+		return err
+	}
+
+	return nil
+}
+
+func migrationRegistry(migrations []Migration) (map[int]Migration, error) {
+	registry := make(map[int]Migration, len(migrations))
+
+	for _, m := range migrations {
+		if _, ok := registry[m.Version]; ok {
+			return nil, fmt.Errorf("duplicate migration version: %d", m.Version)
+		}
+
+		registry[m.Version] = m
+	}
+
+	return registry, nil
+}
+
+func (d *ArangoDBManager) appliedMigrations() ([]appliedMigration, error) {
+	q := arangolite.NewQuery(`
+		FOR x IN @@colName
+		SORT x.version ASC
+		RETURN x
+	`).Bind("@colName", d.migrationCol())
+
+	r, err := d.db.Run(q)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []appliedMigration{}
+
+	if err := json.Unmarshal(r, &applied); err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
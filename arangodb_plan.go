@@ -0,0 +1,355 @@
+package snakepit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/solher/arangolite"
+)
+
+// DryRun enables the dry run mode when w is not nil. The manager then prints
+// to w the plan of the operations it would execute instead of writing to the database.
+func (d *ArangoDBManager) DryRun(w io.Writer) *ArangoDBManager {
+	d.dryRun = w
+	d.dropped = false
+	return d
+}
+
+func (d *ArangoDBManager) planf(format string, a ...interface{}) {
+	fmt.Fprintf(d.dryRun, "  - "+format+"\n", a...)
+}
+
+// collectionNames returns the names of the existing collections.
+// A database planned to be dropped is considered empty.
+func (d *ArangoDBManager) collectionNames() (map[string]bool, error) {
+	names := map[string]bool{}
+
+	if d.dropped {
+		return names, nil
+	}
+
+	r, err := d.db.Run(arangolite.NewQuery(`
+		FOR c IN COLLECTIONS()
+		RETURN c.name
+	`))
+	if err != nil {
+		return nil, err
+	}
+
+	list := []string{}
+
+	if err := json.Unmarshal(r, &list); err != nil {
+		return nil, err
+	}
+
+	for _, name := range list {
+		names[name] = true
+	}
+
+	return names, nil
+}
+
+func (d *ArangoDBManager) planCreate() error {
+	d.planf("create database %s", d.Name)
+	return nil
+}
+
+func (d *ArangoDBManager) planDrop() error {
+	d.planf("drop database %s", d.Name)
+	d.dropped = true
+	return nil
+}
+
+func (d *ArangoDBManager) planMigrate() error {
+	local := reflect.ValueOf(d.localSeed)
+
+	if local.Kind() != reflect.Ptr {
+		return errors.New("invalid seed type: not a pointer")
+	}
+
+	local = local.Elem()
+
+	if local.Kind() != reflect.Struct {
+		return errors.New("invalid seed type: not a struct")
+	}
+
+	existing, err := d.collectionNames()
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < local.NumField(); i++ {
+		field := local.Field(i)
+
+		if field.Kind() != reflect.Slice {
+			continue
+		}
+
+		arrayElem := field.Type().Elem()
+
+		if arrayElem.Kind() != reflect.Struct {
+			return errors.New("invalid seed field type: not a struct")
+		}
+
+		colName := collectionName(local.Type().Field(i), d.naming)
+		colType := "document"
+
+		if _, ok := arrayElem.FieldByName("From"); ok {
+			colType = "edge"
+		}
+
+		indexes, err := parseIndexes(arrayElem)
+		if err != nil {
+			return err
+		}
+
+		create, drop := indexes, []index{}
+
+		if existing[colName] {
+			if create, drop, err = d.diffIndexes(colName, indexes); err != nil {
+				return err
+			}
+		} else {
+			d.planf("create %s collection %s", colType, colName)
+		}
+
+		for _, idx := range drop {
+			d.planf("drop %s index %s on %s(%s)", idx.Type, idx.ID, colName, strings.Join(idx.Fields, ", "))
+		}
+
+		for _, idx := range create {
+			d.planf("create %s index on %s(%s)", idx.Type, colName, strings.Join(idx.Fields, ", "))
+		}
+	}
+
+	graphs, err := parseGraphs(local, d.naming)
+	if err != nil {
+		return err
+	}
+
+	if len(graphs) > 0 {
+		existingGraphs := []graph{}
+
+		if !d.dropped {
+			if existingGraphs, err = d.listGraphs(); err != nil {
+				return err
+			}
+		}
+
+		for _, op := range graphOps(graphs, existingGraphs) {
+			d.planf("%s", op.description)
+		}
+	}
+
+	applied := []appliedMigration{}
+
+	if existing[d.migrationCol()] {
+		if applied, err = d.appliedMigrations(); err != nil {
+			return err
+		}
+	}
+
+	pending, err := pendingMigrations(d.migrations, applied)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		d.planf("apply migration %d %s", m.Version, m.Name)
+	}
+
+	return nil
+}
+
+func (d *ArangoDBManager) planRollback(n int) error {
+	existing, err := d.collectionNames()
+	if err != nil {
+		return err
+	}
+
+	if !existing[d.migrationCol()] {
+		return nil
+	}
+
+	applied, err := d.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for i := len(applied) - 1; i >= 0 && n > 0; i, n = i-1, n-1 {
+		d.planf("revert migration %d %s", applied[i].Version, applied[i].Name)
+	}
+
+	return nil
+}
+
+type seedDocStatus struct {
+	Key    string `json:"key"`
+	Exists bool   `json:"exists"`
+	Same   bool   `json:"same"`
+}
+
+func (d *ArangoDBManager) planSeeds() error {
+	local := reflect.ValueOf(d.localSeed)
+
+	if local.Kind() != reflect.Ptr {
+		return errors.New("invalid seed type: not a pointer")
+	}
+
+	local = local.Elem()
+
+	if local.Kind() != reflect.Struct {
+		return errors.New("invalid seed type: not a struct")
+	}
+
+	if err := ValidateSeed(d.localSeed, d.naming); err != nil {
+		return err
+	}
+
+	existing, err := d.collectionNames()
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < local.NumField(); i++ {
+		field := local.Field(i)
+
+		if field.Kind() != reflect.Slice {
+			continue
+		}
+
+		if field.Len() == 0 && !hasTagOption(local.Type().Field(i).Tag.Get("seed"), "prune") {
+			continue
+		}
+
+		arrayElem := field.Type().Elem()
+
+		if arrayElem.Kind() != reflect.Struct {
+			return errors.New("invalid seed field type: not a struct")
+		}
+
+		colName := collectionName(local.Type().Field(i), d.naming)
+
+		statuses := []seedDocStatus{}
+
+		if existing[colName] && field.Len() > 0 {
+			for _, batch := range seedBatches(field, d.seedBatchSize()) {
+				q := arangolite.NewQuery(`
+					FOR y IN @seed
+					LET x = y._key != "" && y._key != NULL ? DOCUMENT(CONCAT(@colName, "/", y._key)) : NULL
+					LET i = UNSET(x,"_id","_rev")
+					LET j = UNSET(y,"_id","_rev")
+					RETURN { key: y._key, exists: x != NULL, same: x != NULL && MATCHES(i, MERGE(i,j)) }
+				`).Bind("seed", batch).Bind("colName", colName)
+
+				if err := d.runPaged(q, reflect.ValueOf(&statuses)); err != nil {
+					return err
+				}
+			}
+		} else {
+			keys, err := seedKeys(field.Interface())
+			if err != nil {
+				return err
+			}
+
+			for _, key := range keys {
+				statuses = append(statuses, seedDocStatus{Key: key})
+			}
+		}
+
+		forceUpdate := hasTagOption(local.Type().Field(i).Tag.Get("seed"), "forceUpdate")
+		inserted, replaced, skipped, removed := []string{}, []string{}, []string{}, []string{}
+
+		for _, s := range statuses {
+			switch {
+			case s.Key == "":
+				skipped = append(skipped, "<no key>")
+			case !s.Exists:
+				inserted = append(inserted, s.Key)
+			case forceUpdate && !s.Same:
+				replaced = append(replaced, s.Key)
+			default:
+				skipped = append(skipped, s.Key)
+			}
+		}
+
+		if hasTagOption(local.Type().Field(i).Tag.Get("seed"), "prune") && existing[colName] && existing[d.seedCol()] {
+			keys, err := seedKeys(field.Interface())
+			if err != nil {
+				return err
+			}
+
+			if removed, err = d.staleKeys(colName, keys); err != nil {
+				return err
+			}
+		}
+
+		d.planf("seed %s: %d inserted, %d replaced, %d skipped, %d removed", colName, len(inserted), len(replaced), len(skipped), len(removed))
+
+		if len(inserted) > 0 {
+			fmt.Fprintf(d.dryRun, "      insert: %s\n", strings.Join(inserted, ", "))
+		}
+		if len(replaced) > 0 {
+			fmt.Fprintf(d.dryRun, "      replace: %s\n", strings.Join(replaced, ", "))
+		}
+		if len(skipped) > 0 {
+			fmt.Fprintf(d.dryRun, "      skip: %s\n", strings.Join(skipped, ", "))
+		}
+		if len(removed) > 0 {
+			fmt.Fprintf(d.dryRun, "      remove: %s\n", strings.Join(removed, ", "))
+		}
+	}
+
+	return nil
+}
+
+func (d *ArangoDBManager) planRestore(dir string, cols []dumpedCollection) error {
+	existing, err := d.collectionNames()
+	if err != nil {
+		return err
+	}
+
+	for _, col := range cols {
+		if !existing[col.Name] {
+			d.planf("create collection %s", col.Name)
+		}
+
+		count, err := countLines(filepath.Join(dir, col.Name+".jsonl"))
+		if err != nil {
+			return err
+		}
+
+		d.planf("restore %s: %d documents inserted or replaced", col.Name, count)
+	}
+
+	return nil
+}
+
+// countLines returns the number of non empty lines of a file.
+func countLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) > 0 {
+			count++
+		}
+	}
+
+	return count, scanner.Err()
+}
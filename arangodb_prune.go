@@ -0,0 +1,59 @@
+package snakepit
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/solher/arangolite"
+)
+
+// seedColName keeps track of the keys seeded in each collection, so that pruning
+// never touches the documents created by the users.
+const seedColName = "snakepitSeeds"
+
+func (d *ArangoDBManager) seedCol() string {
+	return applyNaming(d.naming, seedColName)
+}
+
+func (d *ArangoDBManager) createSeedCol() error {
+	_, err := d.db.Run(&arangolite.CreateCollection{
+		Name: d.seedCol(),
+		Type: colTypeDoc,
+	})
+	if err != nil && !strings.Contains(err.Error(), "duplicate name") {
+		return err
+	}
+
+	return nil
+}
+
+// staleKeys returns the keys previously seeded in a collection and no longer in the
+// local seed. The seeded keys are read page by page, so that neither list is sent at once.
+func (d *ArangoDBManager) staleKeys(colName string, keys []string) ([]string, error) {
+	q := arangolite.NewQuery(`
+		LET seeded = DOCUMENT(CONCAT(@seedCol, "/", @name))
+		FOR key IN seeded == NULL ? [] : seeded.keys
+		RETURN key
+	`).Bind("seedCol", d.seedCol()).Bind("name", colName)
+
+	seeded := []string{}
+
+	if err := d.runPaged(q, reflect.ValueOf(&seeded)); err != nil {
+		return nil, err
+	}
+
+	local := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		local[key] = true
+	}
+
+	stale := []string{}
+
+	for _, key := range seeded {
+		if !local[key] {
+			stale = append(stale, key)
+		}
+	}
+
+	return stale, nil
+}
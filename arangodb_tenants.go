@@ -0,0 +1,55 @@
+package snakepit
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/solher/arangolite"
+)
+
+// ForTenant returns a copy of the manager bound to the database of a tenant.
+// The copy shares the seeds, migrations and options of the manager.
+func (d *ArangoDBManager) ForTenant(name string) *ArangoDBManager {
+	tenant := *d
+	tenant.db = arangolite.New().LoggerOptions(d.loggerOptions[0], d.loggerOptions[1], d.loggerOptions[2])
+	tenant.dropped = false
+
+	return tenant.Connect(d.URL, name, d.User, d.UserPassword)
+}
+
+// ListDatabases returns the names of the databases starting with prefix,
+// typically to discover the tenant databases.
+func (d *ArangoDBManager) ListDatabases(rootUser, rootPassword, prefix string) ([]string, error) {
+	r, err := d.adminDB(rootUser, rootPassword).Run(&listDatabases{})
+	if err != nil {
+		return nil, err
+	}
+
+	list := []string{}
+
+	if err := json.Unmarshal(r, &list); err != nil {
+		return nil, err
+	}
+
+	return tenantDatabases(list, prefix), nil
+}
+
+// tenantDatabases returns the databases starting with prefix, _system excluded.
+func tenantDatabases(list []string, prefix string) []string {
+	names := []string{}
+
+	for _, name := range list {
+		if name != "_system" && strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+type listDatabases struct{}
+
+func (r *listDatabases) Description() string { return "LIST DATABASES" }
+func (r *listDatabases) Generate() []byte    { return nil }
+func (r *listDatabases) Method() string      { return "GET" }
+func (r *listDatabases) Path() string        { return "/_api/database" }
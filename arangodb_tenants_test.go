@@ -0,0 +1,27 @@
+package snakepit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTenantDatabases(t *testing.T) {
+	list := []string{"_system", "app_acme", "app_globex", "billing", "app"}
+
+	cases := []struct {
+		prefix string
+		names  []string
+	}{
+		{"app_", []string{"app_acme", "app_globex"}},
+		{"app", []string{"app_acme", "app_globex", "app"}},
+		{"", []string{"app_acme", "app_globex", "billing", "app"}},
+		{"_", []string{}},
+		{"crm_", []string{}},
+	}
+
+	for _, c := range cases {
+		if names := tenantDatabases(list, c.prefix); !reflect.DeepEqual(names, c.names) {
+			t.Errorf("%q: got %q, want %q", c.prefix, names, c.names)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package snakepit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/solher/arangolite"
+)
+
+// seedAction runs the seed queries in order, naming the collection of the
+// failing query in the error that aborts the transaction. The staged batches
+// are read back from the staging collection into the bind variables of their step.
+const seedAction = `function (params) {
+	var db = require("@arangodb").db;
+	params.steps.forEach(function (step) {
+		try {
+			Object.keys(step.staged || {}).forEach(function (name) {
+				step.bindVars[name] = db._collection(params.staging).document(step.staged[name]).value;
+			});
+			db._query(step.query, step.bindVars);
+		} catch (e) {
+			throw new Error("collection " + step.collection + ": " + e.message);
+		}
+	});
+}`
+
+// stagingColName holds the seed batches of a transactional SyncSeeds until the
+// transaction applies them.
+const stagingColName = "snakepitStaging"
+
+// Transactional makes SyncSeeds write all the seed collections in a single
+// transaction: either every collection is seeded, or none is. The batches are
+// first uploaded one request at a time to a staging collection, and the transaction
+// reads them from there, so that its request stays small whatever the seed size.
+func (d *ArangoDBManager) Transactional(enabled bool) *ArangoDBManager {
+	d.transactional = enabled
+	return d
+}
+
+type seedStep struct {
+	Collection string                 `json:"collection"`
+	Query      string                 `json:"query"`
+	BindVars   map[string]interface{} `json:"bindVars"`
+	Staged     map[string]string      `json:"staged,omitempty"`
+	// Batch names the bind variable holding the batch, staged in transactional mode.
+	Batch string `json:"-"`
+	// Count and Total report the progress of the batched inserts.
+	Count int `json:"-"`
+	Total int `json:"-"`
+}
+
+func (d *ArangoDBManager) stagingCol() string {
+	return applyNaming(d.naming, stagingColName)
+}
+
+// runSeedTransaction stages the batches of the steps, then applies all the steps
+// in a single transaction. The staged batches are removed in any case.
+func (d *ArangoDBManager) runSeedTransaction(steps []seedStep) error {
+	if len(steps) == 0 {
+		return nil
+	}
+
+	_, err := d.db.Run(&arangolite.CreateCollection{Name: d.stagingCol(), Type: colTypeDoc})
+	if err != nil && !strings.Contains(err.Error(), "duplicate name") {
+		return err
+	}
+
+	run := strconv.FormatInt(time.Now().UnixNano(), 36)
+	staged := []string{}
+	done := map[string]int{}
+
+	defer func() { d.unstage(staged) }()
+
+	for i := range steps {
+		step := &steps[i]
+		if step.Batch == "" {
+			continue
+		}
+
+		key := run + "-" + strconv.Itoa(i)
+
+		q := arangolite.NewQuery(`INSERT { '_key': @key, 'value': @value } IN @@staging`).
+			Bind("key", key).
+			Bind("value", step.BindVars[step.Batch]).
+			Bind("@staging", d.stagingCol())
+
+		if _, err := d.db.Run(q); err != nil {
+			return fmt.Errorf("staging %s failed: %s", step.Collection, err)
+		}
+
+		staged = append(staged, key)
+		delete(step.BindVars, step.Batch)
+		step.Staged = map[string]string{step.Batch: key}
+
+		if step.Count > 0 {
+			done[step.Collection] += step.Count
+			d.progressf("staging %s: %d/%d", step.Collection, done[step.Collection], step.Total)
+		}
+	}
+
+	if _, err := d.db.Run(&seedTransaction{Steps: steps, Staging: d.stagingCol()}); err != nil {
+		return fmt.Errorf("seeding transaction aborted: %s", err)
+	}
+
+	return nil
+}
+
+// unstage removes the staged batches, by batches of keys.
+func (d *ArangoDBManager) unstage(keys []string) {
+	for start := 0; start < len(keys); start += d.seedBatchSize() {
+		end := start + d.seedBatchSize()
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		d.db.Run(arangolite.NewQuery(`
+			FOR key IN @keys
+			REMOVE key IN @@staging OPTIONS { ignoreErrors: true }
+		`).Bind("keys", keys[start:end]).Bind("@staging", d.stagingCol()))
+	}
+}
+
+type seedTransaction struct {
+	Steps   []seedStep
+	Staging string
+}
+
+func (t *seedTransaction) Description() string { return "SEED TRANSACTION" }
+func (t *seedTransaction) Path() string        { return "/_api/transaction" }
+func (t *seedTransaction) Method() string      { return "POST" }
+func (t *seedTransaction) Generate() []byte {
+	write := []string{}
+	seen := map[string]bool{}
+
+	for _, step := range t.Steps {
+		for name, value := range step.BindVars {
+			colName, ok := value.(string)
+			if !strings.HasPrefix(name, "@") || !ok || seen[colName] {
+				continue
+			}
+
+			seen[colName] = true
+			write = append(write, colName)
+		}
+	}
+
+	m, _ := json.Marshal(map[string]interface{}{
+		"collections": map[string]interface{}{"write": write, "read": []string{t.Staging}},
+		"action":      seedAction,
+		"params":      map[string]interface{}{"steps": t.Steps, "staging": t.Staging},
+	})
+	return m
+}
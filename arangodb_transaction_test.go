@@ -0,0 +1,62 @@
+package snakepit
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSeedTransactionGenerate(t *testing.T) {
+	tx := &seedTransaction{
+		Staging: "snakepitStaging",
+		Steps: []seedStep{
+			{
+				Collection: "users",
+				Query:      "FOR x IN @seed INSERT x IN @@colName",
+				BindVars:   map[string]interface{}{"@colName": "users", "@seedCol": "snakepitSeeds"},
+				Staged:     map[string]string{"seed": "run-0"},
+			},
+			{
+				Collection: "users",
+				Query:      "FOR key IN @keys REMOVE key IN @@colName",
+				BindVars:   map[string]interface{}{"@colName": "users"},
+				Staged:     map[string]string{"keys": "run-1"},
+			},
+		},
+	}
+
+	body := struct {
+		Collections struct {
+			Read  []string `json:"read"`
+			Write []string `json:"write"`
+		} `json:"collections"`
+		Params struct {
+			Staging string `json:"staging"`
+			Steps   []struct {
+				BindVars map[string]interface{} `json:"bindVars"`
+				Staged   map[string]string      `json:"staged"`
+			} `json:"steps"`
+		} `json:"params"`
+	}{}
+
+	if err := json.Unmarshal(tx.Generate(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(body.Collections.Read, []string{"snakepitStaging"}) || body.Params.Staging != "snakepitStaging" {
+		t.Errorf("got read %q and staging %q, want the staging collection", body.Collections.Read, body.Params.Staging)
+	}
+
+	if len(body.Collections.Write) != 2 {
+		t.Errorf("got write %q, want users and snakepitSeeds", body.Collections.Write)
+	}
+
+	for i, step := range body.Params.Steps {
+		if _, ok := step.BindVars["seed"]; ok {
+			t.Errorf("step %d: the staged batch is sent in the transaction", i)
+		}
+		if !reflect.DeepEqual(step.Staged, tx.Steps[i].Staged) {
+			t.Errorf("step %d: got staged %v, want %v", i, step.Staged, tx.Steps[i].Staged)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/spf13/viper"
+	"golang.org/x/net/context"
 )
 
 type (
@@ -94,3 +95,24 @@ func NewRepository(
 		JSON:      j,
 	}
 }
+
+// StartSpan starts a child span of the request trace, tagged as a controller one.
+func (c *Controller) StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	return startComponentSpan(ctx, "controller", name)
+}
+
+// StartSpan starts a child span of the request trace, tagged as an interactor one.
+func (i *Interactor) StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	return startComponentSpan(ctx, "interactor", name)
+}
+
+// StartSpan starts a child span of the request trace, tagged as a repository one.
+func (r *Repository) StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	return startComponentSpan(ctx, "repository", name)
+}
+
+func startComponentSpan(ctx context.Context, component, name string) (context.Context, *Span) {
+	ctx, span := StartSpan(ctx, name)
+	span.SetAttribute("component", component)
+	return ctx, span
+}
@@ -1,17 +1,46 @@
 package database
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/solher/snakepit"
 	"github.com/solher/snakepit/root"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+const (
+	DryRun        = "database.dryRun"
+	RollbackSteps = "database.rollback.steps"
+	SeedDir       = "database.seedDir"
+	SeedDiffJSON  = "database.seedDiff.json"
+	SeedGenerate  = "database.seed.generate"
+	SeedRandSeed  = "database.seed.randSeed"
+	Transactional = "database.transactional"
+	BatchSize     = "database.batchSize"
+	DumpDir       = "database.dump.dir"
+	DumpAll       = "database.dump.all"
+	RestoreDir    = "database.restore.dir"
+	Env           = "app.env"
+	ProtectedEnvs = "database.protectedEnvs"
+	Force         = "database.force"
+	Confirm       = "database.confirm"
+)
+
 var Cmd = &cobra.Command{
 	Use:     "database",
 	Aliases: []string{"db"},
 	Short:   "Database management",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if root.Viper.GetBool(DryRun) {
+			fmt.Println("Dry run: no change will be applied to the database.")
+		}
+	},
 }
 
 func init() {
@@ -20,9 +49,124 @@ func init() {
 	Cmd.AddCommand(drop)
 	Cmd.AddCommand(seed)
 	Cmd.AddCommand(reset)
+	Cmd.AddCommand(rollback)
+	Cmd.AddCommand(dump)
+	Cmd.AddCommand(restore)
+
+	Cmd.PersistentFlags().Bool("dry-run", false, "prints the plan without touching the database")
+	root.Viper.BindPFlag(DryRun, Cmd.PersistentFlags().Lookup("dry-run"))
+
+	Cmd.PersistentFlags().String("seed-dir", "", "directory of the seed fixture files")
+	root.Viper.BindPFlag(SeedDir, Cmd.PersistentFlags().Lookup("seed-dir"))
+
+	Cmd.PersistentFlags().Bool("transactional", false, "seeds all the collections in a single transaction")
+	root.Viper.BindPFlag(Transactional, Cmd.PersistentFlags().Lookup("transactional"))
+
+	Cmd.PersistentFlags().Int("batch-size", 1000, "maximum number of seed documents sent in a single request")
+	root.Viper.BindPFlag(BatchSize, Cmd.PersistentFlags().Lookup("batch-size"))
+
+	root.Viper.SetDefault(ProtectedEnvs, []string{"production"})
+
+	Cmd.PersistentFlags().Bool("force", false, "allows dropping the database of a protected environment")
+	root.Viper.BindPFlag(Force, Cmd.PersistentFlags().Lookup("force"))
+
+	Cmd.PersistentFlags().String("confirm", "", "database name (or environment name for several tenants) confirming the drop of a protected environment (non-interactive)")
+	root.Viper.BindPFlag(Confirm, Cmd.PersistentFlags().Lookup("confirm"))
+
+	seed.AddCommand(seedDiff)
+
+	seed.Flags().Int("generate", 0, "number of documents to generate per seed collection having a factory")
+	root.Viper.BindPFlag(SeedGenerate, seed.Flags().Lookup("generate"))
+
+	seed.Flags().Int64("rand-seed", 1, "random seed of the generated documents")
+	root.Viper.BindPFlag(SeedRandSeed, seed.Flags().Lookup("rand-seed"))
+
+	seedDiff.Flags().Bool("json", false, "prints the report in JSON")
+	root.Viper.BindPFlag(SeedDiffJSON, seedDiff.Flags().Lookup("json"))
+
+	dump.Flags().StringP("dir", "d", "./dump", "dump directory")
+	root.Viper.BindPFlag(DumpDir, dump.Flags().Lookup("dir"))
+
+	dump.Flags().Bool("all", false, "dumps all the collections instead of the seed ones")
+	root.Viper.BindPFlag(DumpAll, dump.Flags().Lookup("all"))
+
+	restore.Flags().StringP("dir", "d", "./dump", "dump directory")
+	root.Viper.BindPFlag(RestoreDir, restore.Flags().Lookup("dir"))
+
+	rollback.Flags().IntP("steps", "n", 1, "number of migrations to revert")
+	root.Viper.BindPFlag(RollbackSteps, rollback.Flags().Lookup("steps"))
 }
 
-var Create, Migrate, Drop, Seed func(v *viper.Viper) error
+var Create, Migrate, Rollback, Drop, Seed, SeedDiff, Dump, Restore func(v *viper.Viper) error
+
+// Name returns the app database name, typed by the user to confirm its drop on a protected environment.
+var Name func(v *viper.Viper) string
+
+// stdin is shared by the confirmation prompts, so that piped input is never lost between them.
+var stdin = bufio.NewReader(os.Stdin)
+
+// guard refuses to drop the databases of a protected environment unless forced
+// and confirmed once, either typed or given by --confirm. A single database is
+// confirmed by its name, several tenant databases by the environment name.
+func guard(v *viper.Viper, tenants []string) error {
+	if v.GetBool(DryRun) {
+		return nil
+	}
+
+	env := v.GetString(Env)
+
+	protected := false
+	for _, e := range v.GetStringSlice(ProtectedEnvs) {
+		if e == env {
+			protected = true
+			break
+		}
+	}
+
+	if !protected {
+		return nil
+	}
+
+	if !v.GetBool(Force) {
+		return fmt.Errorf("%s is a protected environment: use --force to drop its database", env)
+	}
+
+	expected := env
+
+	switch len(tenants) {
+	case 0, 1:
+		if Name == nil {
+			return errors.New("nil name func")
+		}
+
+		if len(tenants) == 1 {
+			v.Set(Tenant, tenants[0])
+			defer v.Set(Tenant, "")
+		}
+
+		expected = Name(v)
+	}
+
+	confirm := v.GetString(Confirm)
+
+	if confirm == "" {
+		if len(tenants) > 1 {
+			fmt.Printf("You are about to drop the %d tenant databases of the %s environment: %s.\n", len(tenants), env, strings.Join(tenants, ", "))
+			fmt.Print("Type the environment name to confirm: ")
+		} else {
+			fmt.Printf("You are about to drop the %s database of the %s environment.\n", expected, env)
+			fmt.Print("Type the database name to confirm: ")
+		}
+
+		confirm, _ = stdin.ReadString('\n')
+	}
+
+	if strings.TrimSpace(confirm) != expected {
+		return errors.New("confirmation failed: name mismatch")
+	}
+
+	return nil
+}
 
 var create = &cobra.Command{
 	Use:   "create",
@@ -30,7 +174,7 @@ var create = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("Creating database...")
 
-		if err := Create(root.Viper); err != nil {
+		if err := forEachTenant(Create); err != nil {
 			return err
 		}
 
@@ -42,11 +186,27 @@ var create = &cobra.Command{
 
 var migrate = &cobra.Command{
 	Use:   "migrate",
-	Short: "Creates the app collections in the database",
+	Short: "Creates the app collections and applies the pending migrations",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("Migrating database...")
 
-		if err := Migrate(root.Viper); err != nil {
+		if err := forEachTenant(Migrate); err != nil {
+			return err
+		}
+
+		fmt.Println("Done.")
+
+		return nil
+	},
+}
+
+var rollback = &cobra.Command{
+	Use:   "rollback",
+	Short: "Reverts the last applied migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println("Rolling back migrations...")
+
+		if err := forEachTenant(Rollback); err != nil {
 			return err
 		}
 
@@ -62,7 +222,7 @@ var seed = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("Seeding database...")
 
-		if err := Seed(root.Viper); err != nil {
+		if err := forEachTenant(Seed); err != nil {
 			return err
 		}
 
@@ -72,13 +232,86 @@ var seed = &cobra.Command{
 	},
 }
 
+var seedDiff = &cobra.Command{
+	Use:   "diff",
+	Short: "Reports the differences between the local and distant seeds",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		return forEachTenant(func(v *viper.Viper) error {
+			err := SeedDiff(v)
+
+			drift, ok := err.(*snakepit.SeedDriftError)
+			if !ok {
+				if err == nil {
+					fmt.Println("Seeds synchronized.")
+				}
+				return err
+			}
+
+			if v.GetBool(SeedDiffJSON) {
+				buf, err := json.MarshalIndent(drift, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(buf))
+			} else {
+				drift.Print(os.Stdout)
+			}
+
+			return drift
+		})
+	},
+}
+
 var drop = &cobra.Command{
 	Use:   "drop",
 	Short: "Drops the app database",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("Dropping database...")
 
-		if err := Drop(root.Viper); err != nil {
+		selected, err := selectedTenants(root.Viper)
+		if err != nil {
+			return err
+		}
+
+		if err := guard(root.Viper, selected); err != nil {
+			return err
+		}
+
+		if err := runTenants(selected, Drop); err != nil {
+			return err
+		}
+
+		fmt.Println("Done.")
+
+		return nil
+	},
+}
+
+var dump = &cobra.Command{
+	Use:   "dump",
+	Short: "Dumps the app collections to newline delimited JSON files",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println("Dumping database...")
+
+		if err := forEachTenant(Dump); err != nil {
+			return err
+		}
+
+		fmt.Println("Done.")
+
+		return nil
+	},
+}
+
+var restore = &cobra.Command{
+	Use:   "restore",
+	Short: "Restores the app collections from a dump",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println("Restoring database...")
+
+		if err := forEachTenant(Restore); err != nil {
 			return err
 		}
 
@@ -94,19 +327,31 @@ var reset = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("Resetting database...")
 
-		if err := Drop(root.Viper); err != nil {
+		selected, err := selectedTenants(root.Viper)
+		if err != nil {
 			return err
 		}
 
-		if err := Create(root.Viper); err != nil {
+		if err := guard(root.Viper, selected); err != nil {
 			return err
 		}
 
-		if err := Migrate(root.Viper); err != nil {
-			return err
-		}
+		err = runTenants(selected, func(v *viper.Viper) error {
+			if err := Drop(v); err != nil {
+				return err
+			}
+
+			if err := Create(v); err != nil {
+				return err
+			}
+
+			if err := Migrate(v); err != nil {
+				return err
+			}
 
-		if err := Seed(root.Viper); err != nil {
+			return Seed(v)
+		})
+		if err != nil {
 			return err
 		}
 
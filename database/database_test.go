@@ -0,0 +1,46 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestGuard(t *testing.T) {
+	Name = func(v *viper.Viper) string {
+		if tenant := v.GetString(Tenant); tenant != "" {
+			return "app_" + tenant
+		}
+		return "app"
+	}
+	defer func() { Name = nil }()
+
+	cases := []struct {
+		env     string
+		force   bool
+		confirm string
+		tenants []string
+		ok      bool
+	}{
+		{env: "staging", ok: true},
+		{env: "production", confirm: "app"},
+		{env: "production", force: true, confirm: "app", ok: true},
+		{env: "production", force: true, confirm: "wrong"},
+		{env: "production", force: true, confirm: "app_acme", tenants: []string{"acme"}, ok: true},
+		// Several tenants are confirmed once, by the environment name.
+		{env: "production", force: true, confirm: "production", tenants: []string{"acme", "globex"}, ok: true},
+		{env: "production", force: true, confirm: "app_acme", tenants: []string{"acme", "globex"}},
+	}
+
+	for _, c := range cases {
+		v := viper.New()
+		v.Set(ProtectedEnvs, []string{"production"})
+		v.Set(Env, c.env)
+		v.Set(Force, c.force)
+		v.Set(Confirm, c.confirm)
+
+		if err := guard(v, c.tenants); (err == nil) != c.ok {
+			t.Errorf("%+v: unexpected error %v", c, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/solher/snakepit/root"
+	"github.com/spf13/viper"
+)
+
+const (
+	Tenant     = "database.tenant"
+	Tenants    = "database.tenants"
+	Select     = "database.select.tenants"
+	AllTenants = "database.select.all"
+)
+
+// DiscoverTenants returns the tenants targeted by --all-tenants, for example the
+// databases starting with a given prefix. Defaults to the database.tenants config list.
+var DiscoverTenants func(v *viper.Viper) ([]string, error)
+
+func init() {
+	Cmd.PersistentFlags().StringSlice("tenant", nil, "tenant to run the command for (repeatable)")
+	root.Viper.BindPFlag(Select, Cmd.PersistentFlags().Lookup("tenant"))
+
+	Cmd.PersistentFlags().Bool("all-tenants", false, "runs the command for all the tenants")
+	root.Viper.BindPFlag(AllTenants, Cmd.PersistentFlags().Lookup("all-tenants"))
+}
+
+// selectedTenants returns the tenants selected by --tenant or --all-tenants.
+func selectedTenants(v *viper.Viper) ([]string, error) {
+	if !v.GetBool(AllTenants) {
+		return v.GetStringSlice(Select), nil
+	}
+
+	if DiscoverTenants != nil {
+		return DiscoverTenants(v)
+	}
+
+	return v.GetStringSlice(Tenants), nil
+}
+
+// forEachTenant runs fn once per selected tenant.
+func forEachTenant(fn func(v *viper.Viper) error) error {
+	selected, err := selectedTenants(root.Viper)
+	if err != nil {
+		return err
+	}
+
+	return runTenants(selected, fn)
+}
+
+// runTenants runs fn once per tenant, setting database.tenant accordingly.
+// Failures are reported per tenant without stopping the others.
+// Without tenant, fn is simply run once.
+func runTenants(selected []string, fn func(v *viper.Viper) error) error {
+	if len(selected) == 0 {
+		return fn(root.Viper)
+	}
+
+	defer root.Viper.Set(Tenant, "")
+
+	failed := []string{}
+
+	for _, t := range selected {
+		root.Viper.Set(Tenant, t)
+
+		if err := fn(root.Viper); err != nil {
+			fmt.Printf("  %s: failed: %s\n", t, err)
+			failed = append(failed, t)
+			continue
+		}
+
+		fmt.Printf("  %s: done\n", t)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed tenants: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
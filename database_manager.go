@@ -0,0 +1,53 @@
+package snakepit
+
+import "strings"
+
+// DatabaseManager defines the operations the database commands rely on.
+// The seed conventions are shared by every implementation: each slice field of
+// the seed struct is a collection named by the NamingStrategy (or its `collection`
+// tag), an element type with a From field makes an edge collection,
+// `seed:"forceUpdate"` replaces the distant documents, `seed:"prune"` removes the
+// previously seeded documents no longer in the seed and `check:"keyOnly"` only
+// checks their keys. Only the keys actually inserted are recorded as seeded, so that
+// a document created by a user before being added to the seed is never pruned.
+type DatabaseManager interface {
+	Create(rootUser, rootPassword string) error
+	Migrate() error
+	Drop(rootUser, rootPassword string) error
+	SyncSeeds() error
+	LoadDistantSeed() error
+}
+
+var (
+	_ DatabaseManager = (*ArangoDBManager)(nil)
+	_ DatabaseManager = (*MemoryManager)(nil)
+)
+
+// hasTagOption reports whether a comma separated tag contains the given option.
+func hasTagOption(tag, option string) bool {
+	for _, opt := range strings.Split(tag, ",") {
+		if opt == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// seedKeys returns the keys of the documents of a seed slice.
+func seedKeys(seed interface{}) ([]string, error) {
+	docs, err := toDocuments(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(docs))
+
+	for _, doc := range docs {
+		if key, _ := doc["_key"].(string); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
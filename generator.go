@@ -0,0 +1,237 @@
+package snakepit
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	firstNames = []string{"Alice", "Bob", "Chloe", "David", "Emma", "Felix", "Grace", "Hugo", "Iris", "Jules", "Karen", "Louis", "Mia", "Noah", "Olivia", "Paul"}
+	lastNames  = []string{"Martin", "Bernard", "Dubois", "Smith", "Johnson", "Garcia", "Miller", "Davis", "Lopez", "Wilson", "Moore", "Taylor", "Thomas", "Moreau"}
+	words      = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do", "eiusmod", "tempor", "incididunt", "labore", "magna", "aliqua"}
+	domains    = []string{"example.com", "example.org", "example.net"}
+)
+
+// Factory returns the i-th generated element of a seed collection.
+// The returned value must have the type of the seed slice elements.
+type Factory func(g *Generator, i int) interface{}
+
+// Generator fills seed collections with generated documents. Its random source is
+// seeded explicitly, so that the same seed always produces the same fixtures.
+type Generator struct {
+	Rand      *rand.Rand
+	names     []string
+	factories map[string]Factory
+	refs      map[string][]string
+}
+
+func NewGenerator(seed int64) *Generator {
+	return &Generator{
+		Rand:      rand.New(rand.NewSource(seed)),
+		factories: map[string]Factory{},
+		refs:      map[string][]string{},
+	}
+}
+
+// Register sets the factory of the seed collection defined by the given seed struct field name.
+func (g *Generator) Register(field string, factory Factory) *Generator {
+	if _, ok := g.factories[field]; !ok {
+		g.names = append(g.names, field)
+	}
+	g.factories[field] = factory
+
+	return g
+}
+
+// Generate appends n generated documents to each seed collection having a factory.
+// Document collections are generated before edge collections, so that edge
+// factories can link generated documents with Ref.
+func (g *Generator) Generate(seed Seed, n int, naming NamingStrategy) error {
+	local := reflect.ValueOf(seed)
+
+	if local.Kind() != reflect.Ptr {
+		return errors.New("invalid seed type: not a pointer")
+	}
+
+	local = local.Elem()
+
+	if local.Kind() != reflect.Struct {
+		return errors.New("invalid seed type: not a struct")
+	}
+
+	docs, edges := []string{}, []string{}
+
+	for _, name := range g.names {
+		field, ok := local.Type().FieldByName(name)
+		if !ok || field.Type.Kind() != reflect.Slice || field.Type.Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("invalid factory: no seed collection %s", name)
+		}
+
+		if _, ok := field.Type.Elem().FieldByName("From"); ok {
+			edges = append(edges, name)
+		} else {
+			docs = append(docs, name)
+		}
+	}
+
+	// The refs of every collection, generated or not, are available to the factories.
+	for i := 0; i < local.NumField(); i++ {
+		if err := g.indexRefs(local, local.Type().Field(i), naming); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range append(docs, edges...) {
+		sf, _ := local.Type().FieldByName(name)
+		field := local.FieldByName(name)
+		elemType := field.Type().Elem()
+
+		for i := 0; i < n; i++ {
+			elem := reflect.ValueOf(g.factories[name](g, i))
+
+			if !elem.IsValid() || elem.Type() != elemType {
+				return fmt.Errorf("invalid factory %s: must return a %s", name, elemType)
+			}
+
+			field.Set(reflect.Append(field, elem))
+		}
+
+		if err := g.indexRefs(local, sf, naming); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Generator) indexRefs(local reflect.Value, sf reflect.StructField, naming NamingStrategy) error {
+	if sf.Type.Kind() != reflect.Slice {
+		return nil
+	}
+
+	keys, err := seedKeys(local.FieldByIndex(sf.Index).Interface())
+	if err != nil {
+		return err
+	}
+
+	colName := collectionName(sf, naming)
+	refs := make([]string, len(keys))
+
+	for i, key := range keys {
+		refs[i] = colName + "/" + key
+	}
+
+	g.refs[sf.Name] = refs
+
+	return nil
+}
+
+// Ref returns the id of a random document of the seed collection defined by the
+// given seed struct field name, typically to set the _from or _to of an edge.
+// It returns an empty string if the collection is empty.
+func (g *Generator) Ref(field string) string {
+	refs := g.refs[field]
+	if len(refs) == 0 {
+		return ""
+	}
+
+	return refs[g.Rand.Intn(len(refs))]
+}
+
+// Edge returns the ids of two random documents to link.
+func (g *Generator) Edge(fromField, toField string) (from, to string) {
+	return g.Ref(fromField), g.Ref(toField)
+}
+
+// Key returns a random document key.
+func (g *Generator) Key() string {
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	key := make([]byte, 12)
+	for i := range key {
+		key[i] = chars[g.Rand.Intn(len(chars))]
+	}
+
+	return string(key)
+}
+
+// Int returns a random int in [min, max].
+func (g *Generator) Int(min, max int) int {
+	return min + g.Rand.Intn(max-min+1)
+}
+
+// Float returns a random float64 in [min, max).
+func (g *Generator) Float(min, max float64) float64 {
+	return min + g.Rand.Float64()*(max-min)
+}
+
+func (g *Generator) Bool() bool {
+	return g.Rand.Intn(2) == 1
+}
+
+// OneOf returns one of the given options.
+func (g *Generator) OneOf(options ...string) string {
+	return options[g.Rand.Intn(len(options))]
+}
+
+// Time returns a random time between from and to.
+func (g *Generator) Time(from, to time.Time) time.Time {
+	return from.Add(time.Duration(g.Rand.Int63n(int64(to.Sub(from)) + 1))).UTC()
+}
+
+func (g *Generator) FirstName() string {
+	return g.OneOf(firstNames...)
+}
+
+func (g *Generator) LastName() string {
+	return g.OneOf(lastNames...)
+}
+
+func (g *Generator) Name() string {
+	return g.FirstName() + " " + g.LastName()
+}
+
+// Email returns a random email address, made unique by a random suffix.
+func (g *Generator) Email() string {
+	return fmt.Sprintf("%s.%s.%d@%s",
+		strings.ToLower(g.FirstName()),
+		strings.ToLower(g.LastName()),
+		g.Rand.Intn(100000),
+		g.OneOf(domains...),
+	)
+}
+
+func (g *Generator) Word() string {
+	return g.OneOf(words...)
+}
+
+// Sentence returns n random words.
+func (g *Generator) Sentence(n int) string {
+	s := make([]string, n)
+	for i := range s {
+		s[i] = g.Word()
+	}
+
+	if n > 0 {
+		s[0] = strings.ToUpper(s[0][0:1]) + s[0][1:]
+	}
+
+	return strings.Join(s, " ") + "."
+}
+
+// Generate appends n generated documents to the local seed collections having a factory.
+func (d *ArangoDBManager) Generate(g *Generator, n int) error {
+	return g.Generate(d.localSeed, n, d.naming)
+}
+
+// Generate appends n generated documents to the local seed collections having a factory.
+func (m *MemoryManager) Generate(g *Generator, n int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return g.Generate(m.localSeed, n, m.naming)
+}
@@ -0,0 +1,153 @@
+package snakepit
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pressly/chi"
+	"golang.org/x/net/context"
+)
+
+var APINotReady = APIError{
+	Description: "The service is not ready.",
+	ErrorCode:   "NOT_READY",
+}
+
+// HealthCheck probes a dependency, returning an error if it is unavailable.
+type HealthCheck func(ctx context.Context) error
+
+type healthCheckResult struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Health is a registry of health checks. Its middleware serves "/healthz",
+// always answering 200 while the process is alive, and "/readyz", running all the
+// registered checks and answering 503 if any of them fails.
+type Health struct {
+	JSON    *JSON
+	mutex   sync.RWMutex
+	names   []string
+	checks  map[string]HealthCheck
+	timeout time.Duration
+}
+
+func NewHealth(j *JSON) *Health {
+	return &Health{
+		JSON:    j,
+		checks:  map[string]HealthCheck{},
+		timeout: 5 * time.Second,
+	}
+}
+
+// Register adds a named check to the readiness probe.
+func (h *Health) Register(name string, check HealthCheck) *Health {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, ok := h.checks[name]; !ok {
+		h.names = append(h.names, name)
+	}
+	h.checks[name] = check
+
+	return h
+}
+
+// Timeout sets the duration after which a check is considered failed.
+func (h *Health) Timeout(timeout time.Duration) *Health {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.timeout = timeout
+
+	return h
+}
+
+func (h *Health) Middleware(next chi.Handler) chi.Handler {
+	return chi.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			h.JSON.Render(ctx, w, http.StatusOK, map[string]string{"status": "ok"})
+		case "/readyz":
+			results, ready := h.check(ctx)
+
+			if !ready {
+				apiErr := APINotReady
+				apiErr.Status = http.StatusServiceUnavailable
+				apiErr.Params = map[string]interface{}{"checks": results}
+
+				h.JSON.Render(ctx, w, http.StatusServiceUnavailable, apiErr)
+				return
+			}
+
+			h.JSON.Render(ctx, w, http.StatusOK, map[string]interface{}{
+				"status": "ok",
+				"checks": results,
+			})
+		default:
+			next.ServeHTTPC(ctx, w, r)
+		}
+	})
+}
+
+// check runs all the registered checks concurrently.
+func (h *Health) check(ctx context.Context) (map[string]healthCheckResult, bool) {
+	h.mutex.RLock()
+	names := append([]string{}, h.names...)
+	checks := make([]HealthCheck, len(names))
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	timeout := h.timeout
+	h.mutex.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make([]healthCheckResult, len(names))
+	wg := sync.WaitGroup{}
+
+	for i := range checks {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			start := time.Now()
+			done := make(chan error, 1)
+
+			go func() { done <- checks[i](ctx) }()
+
+			var err error
+
+			select {
+			case err = <-done:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+
+			results[i] = healthCheckResult{Status: "ok", Latency: time.Since(start).String()}
+
+			if err != nil {
+				results[i].Status = "failed"
+				results[i].Error = err.Error()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	ready := true
+	byName := make(map[string]healthCheckResult, len(names))
+
+	for i, name := range names {
+		byName[name] = results[i]
+		if results[i].Status != "ok" {
+			ready = false
+		}
+	}
+
+	return byName, ready
+}
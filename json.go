@@ -28,7 +28,11 @@ var (
 	}
 )
 
-type JSON struct{}
+// JSON renders and decodes the API payloads. When Metrics is set, the
+// marshalling and unmarshalling durations are recorded.
+type JSON struct {
+	Metrics *Metrics
+}
 
 func NewJSON() *JSON {
 	return &JSON{}
@@ -135,6 +139,7 @@ func (j *JSON) Unmarshal(
 	}
 
 	LogTime(l, name+" unmarshalling", start)
+	j.Metrics.ObserveJSON("unmarshal", name, start)
 
 	return nil
 }
@@ -152,6 +157,7 @@ func (j *JSON) Marshal(
 	}
 
 	LogTime(l, name+" marshalling", start)
+	j.Metrics.ObserveJSON("marshal", name, start)
 
 	return buf, nil
 }
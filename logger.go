@@ -2,8 +2,10 @@ package snakepit
 
 import (
 	"errors"
+	"fmt"
+	"math/rand"
+	"net"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -58,17 +60,85 @@ func GetResLogEntry(ctx context.Context) (*logrus.Entry, error) {
 	return entry, nil
 }
 
-var (
-	xForwardedFor = http.CanonicalHeaderKey("X-Forwarded-For")
-	xRealIP       = http.CanonicalHeaderKey("X-Real-IP")
+// AccessLogFormat selects how the Logger middleware logs the requests.
+type AccessLogFormat int
+
+const (
+	// TwoLines logs a line when the request arrives and another when it is served.
+	TwoLines AccessLogFormat = iota
+	// SingleLine logs a single logrus entry once the request is served.
+	SingleLine
+	// Combined logs an Apache/NCSA combined log format line as the entry message.
+	// Pair it with a formatter printing the bare message to get a raw access log.
+	Combined
+	// JSONLine logs a single entry made of the configured fields, meant to be
+	// printed by a logrus.JSONFormatter. Like the other formats, it keeps the fields
+	// set on the response entry, such as the error rendered by JSON.RenderError.
+	JSONLine
 )
 
+// Access log fields, added to the SingleLine and JSONLine formats with LogFields.
+const (
+	FieldUserAgent = "userAgent"
+	FieldReferer   = "referer"
+	FieldBytes     = "bytes"
+	FieldRoute     = "route"
+	FieldQuery     = "query"
+)
+
+// LoggerOption configures the Logger middleware.
+type LoggerOption func(l *Logger)
+
+// LogFormat sets the access log format, TwoLines by default.
+func LogFormat(format AccessLogFormat) LoggerOption {
+	return func(l *Logger) {
+		l.format = format
+	}
+}
+
+// LogFields adds optional fields to the SingleLine and JSONLine formats.
+func LogFields(fields ...string) LoggerOption {
+	return func(l *Logger) {
+		l.fields = fields
+	}
+}
+
+// LogSampling only logs the given ratio of the requests, between 0 and 1.
+// The server errors are always logged.
+func LogSampling(rate float64) LoggerOption {
+	return func(l *Logger) {
+		l.sampling = rate
+	}
+}
+
+// ExcludePaths disables the access log of the given paths, such as "/healthz".
+func ExcludePaths(paths ...string) LoggerOption {
+	return func(l *Logger) {
+		for _, path := range paths {
+			l.excluded[path] = true
+		}
+	}
+}
+
 type Logger struct {
-	log *logrus.Logger
+	log      *logrus.Logger
+	format   AccessLogFormat
+	fields   []string
+	sampling float64
+	excluded map[string]bool
 }
 
-func NewLogger(log *logrus.Logger) func(next chi.Handler) chi.Handler {
-	logger := &Logger{log: log}
+func NewLogger(log *logrus.Logger, options ...LoggerOption) func(next chi.Handler) chi.Handler {
+	logger := &Logger{
+		log:      log,
+		sampling: 1,
+		excluded: map[string]bool{},
+	}
+
+	for _, option := range options {
+		option(logger)
+	}
+
 	return logger.middleware
 }
 
@@ -76,8 +146,9 @@ func (l *Logger) middleware(next chi.Handler) chi.Handler {
 	return chi.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		if rip := l.realIP(r); rip != "" {
-			r.RemoteAddr = rip
+		remote := r.RemoteAddr
+		if rip, err := GetRealIP(ctx); err == nil {
+			remote = rip
 		}
 
 		reqID, _ := GetRequestID(ctx)
@@ -89,9 +160,20 @@ func (l *Logger) middleware(next chi.Handler) chi.Handler {
 		entry := logger.WithFields(logrus.Fields{
 			"uri":    r.RequestURI,
 			"method": r.Method,
-			"remote": r.RemoteAddr,
+			"remote": remote,
 		})
-		entry.Info("Request arrived.")
+
+		logged := !l.excluded[r.URL.Path] && (l.sampling >= 1 || rand.Float64() < l.sampling)
+
+		if logged && l.format == TwoLines {
+			entry.Info("Request arrived.")
+		}
+
+		route, ok := ctx.Value(contextRoute).(*string)
+		if !ok || route == nil {
+			route = new(string)
+			ctx = context.WithValue(ctx, contextRoute, route)
+		}
 
 		ctx = context.WithValue(ctx, contextLogger, logger)
 		ctx = context.WithValue(ctx, contextResLogEntry, entry)
@@ -102,6 +184,21 @@ func (l *Logger) middleware(next chi.Handler) chi.Handler {
 
 		status := proxy.status()
 
+		if !logged && !(status >= 500 && status < 600) {
+			return
+		}
+
+		switch l.format {
+		case Combined:
+			l.print(entry, status, l.combined(r, proxy, start, remote))
+			return
+		case JSONLine:
+			l.print(entry.WithFields(l.jsonFields(r, proxy, start, remote, *route, reqID)), status, "Request served.")
+			return
+		case SingleLine:
+			entry = entry.WithFields(l.extraFields(r, proxy, *route))
+		}
+
 		entry = entry.WithFields(logrus.Fields{
 			"status":  status,
 			"latency": time.Since(start),
@@ -116,20 +213,84 @@ func (l *Logger) middleware(next chi.Handler) chi.Handler {
 	})
 }
 
-func (l *Logger) realIP(r *http.Request) string {
-	var ip string
+func (l *Logger) extraFields(r *http.Request, proxy writerProxy, route string) logrus.Fields {
+	fields := logrus.Fields{}
 
-	if xff := r.Header.Get(xForwardedFor); xff != "" {
-		i := strings.Index(xff, ", ")
-		if i == -1 {
-			i = len(xff)
+	for _, field := range l.fields {
+		switch field {
+		case FieldUserAgent:
+			fields[field] = r.UserAgent()
+		case FieldReferer:
+			fields[field] = r.Referer()
+		case FieldBytes:
+			fields[field] = proxy.bytesWritten()
+		case FieldRoute:
+			fields[field] = route
+		case FieldQuery:
+			fields[field] = r.URL.RawQuery
 		}
-		ip = xff[:i]
-	} else if xrip := r.Header.Get(xRealIP); xrip != "" {
-		ip = xrip
 	}
 
-	return ip
+	return fields
+}
+
+// combined formats the request in the Apache/NCSA combined log format.
+func (l *Logger) combined(r *http.Request, proxy writerProxy, start time.Time, remote string) string {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+
+	user := "-"
+	if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	} else if u, _, ok := r.BasicAuth(); ok && u != "" {
+		user = u
+	}
+
+	referer, userAgent := r.Referer(), r.UserAgent()
+	if referer == "" {
+		referer = "-"
+	}
+	if userAgent == "" {
+		userAgent = "-"
+	}
+
+	return fmt.Sprintf("%s - %s [%s] %q %d %d %q %q",
+		host,
+		user,
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method+" "+r.RequestURI+" "+r.Proto,
+		proxy.status(),
+		proxy.bytesWritten(),
+		referer,
+		userAgent,
+	)
+}
+
+// jsonFields returns the fields of a JSONLine entry. The timestamp is left to the formatter.
+func (l *Logger) jsonFields(r *http.Request, proxy writerProxy, start time.Time, remote, route, reqID string) logrus.Fields {
+	fields := l.extraFields(r, proxy, route)
+
+	fields["reqId"] = reqID
+	fields["method"] = r.Method
+	fields["uri"] = r.RequestURI
+	fields["remote"] = remote
+	fields["status"] = proxy.status()
+	fields["latency"] = time.Since(start).String()
+
+	return fields
+}
+
+// print logs the message through the logrus entry, so that the logger lock,
+// formatter and hooks apply. The server errors are logged at the error level.
+func (l *Logger) print(entry *logrus.Entry, status int, message string) {
+	if status >= 500 && status < 600 {
+		entry.Error(message)
+		return
+	}
+
+	entry.Info(message)
 }
 
 func LogTime(log *logrus.Entry, name string, start time.Time) {
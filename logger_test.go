@@ -0,0 +1,48 @@
+package snakepit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pressly/chi"
+	"golang.org/x/net/context"
+)
+
+func TestLoggerJSONLineRenderedError(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	log := logrus.New()
+	log.Out = buf
+	log.Formatter = &logrus.JSONFormatter{}
+
+	handler := NewLogger(log, LogFormat(JSONLine))(chi.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		NewJSON().RenderError(ctx, w, http.StatusInternalServerError, APIInternal, errors.New("boom"))
+	}))
+
+	handler.ServeHTTPC(context.Background(), httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+
+	line := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("invalid access line %q: %s", buf.String(), err)
+	}
+
+	if line["error"] != "boom" {
+		t.Errorf("got error %v, want %q", line["error"], "boom")
+	}
+	for _, field := range []string{"location", "stacktrace"} {
+		if _, ok := line[field]; !ok {
+			t.Errorf("missing %s", field)
+		}
+	}
+	if line["status"] != float64(http.StatusInternalServerError) {
+		t.Errorf("got status %v, want %d", line["status"], http.StatusInternalServerError)
+	}
+	if line["level"] != "error" {
+		t.Errorf("got level %v, want %q", line["level"], "error")
+	}
+}
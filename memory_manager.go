@@ -0,0 +1,432 @@
+package snakepit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type memoryCollection struct {
+	edge   bool
+	docs   map[string]map[string]interface{}
+	seeded map[string]bool
+}
+
+// MemoryManager is an in-memory DatabaseManager, mainly meant to test seeds
+// without a running database.
+type MemoryManager struct {
+	mutex                  sync.Mutex
+	localSeed, distantSeed Seed
+	created                bool
+	naming                 NamingStrategy
+	cols                   map[string]*memoryCollection
+	migrations             []Migration
+	applied                []appliedMigration
+}
+
+func NewMemoryManager(localSeed, distantSeed Seed) *MemoryManager {
+	return &MemoryManager{
+		localSeed:   localSeed,
+		distantSeed: distantSeed,
+		cols:        map[string]*memoryCollection{},
+	}
+}
+
+// Naming sets the strategy deriving the collection names from the seed fields.
+func (m *MemoryManager) Naming(strategy NamingStrategy) *MemoryManager {
+	m.naming = strategy
+	return m
+}
+
+func (m *MemoryManager) Create(rootUser, rootPassword string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.created = true
+
+	return nil
+}
+
+// RegisterMigrations adds migrations to the manager registry, applied like
+// the ArangoDBManager ones by Migrate.
+func (m *MemoryManager) RegisterMigrations(migrations ...Migration) *MemoryManager {
+	m.migrations = append(m.migrations, migrations...)
+	return m
+}
+
+// Migrate creates the seed collections, then applies the pending migrations.
+// The migrations run unlocked, so that they can call the manager.
+func (m *MemoryManager) Migrate() error {
+	if err := m.migrate(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	pending, err := pendingMigrations(m.migrations, m.applied)
+	m.mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range pending {
+		if err := migration.Up(m); err != nil {
+			return fmt.Errorf("migration %d failed: %s", migration.Version, err)
+		}
+
+		m.mutex.Lock()
+		m.applied = append(m.applied, appliedMigration{
+			Key:       strconv.Itoa(migration.Version),
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now().UTC(),
+		})
+		m.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// Rollback reverts the last n applied migrations, in reverse order.
+func (m *MemoryManager) Rollback(n int) error {
+	registry, err := migrationRegistry(m.migrations)
+	if err != nil {
+		return err
+	}
+
+	for ; n > 0; n-- {
+		m.mutex.Lock()
+		if len(m.applied) == 0 {
+			m.mutex.Unlock()
+			return nil
+		}
+		last := m.applied[len(m.applied)-1]
+		m.mutex.Unlock()
+
+		migration, ok := registry[last.Version]
+		if !ok {
+			return fmt.Errorf("unknown applied migration: %d", last.Version)
+		}
+
+		if migration.Down == nil {
+			return fmt.Errorf("irreversible migration: %d", migration.Version)
+		}
+
+		if err := migration.Down(m); err != nil {
+			return fmt.Errorf("migration %d rollback failed: %s", migration.Version, err)
+		}
+
+		m.mutex.Lock()
+		m.applied = m.applied[:len(m.applied)-1]
+		m.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (m *MemoryManager) migrate() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.created {
+		return errors.New("database not found")
+	}
+
+	local := reflect.ValueOf(m.localSeed)
+
+	if local.Kind() != reflect.Ptr {
+		return errors.New("invalid seed type: not a pointer")
+	}
+
+	local = local.Elem()
+
+	if local.Kind() != reflect.Struct {
+		return errors.New("invalid seed type: not a struct")
+	}
+
+	if _, err := parseGraphs(local, m.naming); err != nil {
+		return err
+	}
+
+	for i := 0; i < local.NumField(); i++ {
+		field := local.Field(i)
+
+		if field.Kind() != reflect.Slice {
+			continue
+		}
+
+		arrayElem := field.Type().Elem()
+
+		if arrayElem.Kind() != reflect.Struct {
+			return errors.New("invalid seed field type: not a struct")
+		}
+
+		colName := collectionName(local.Type().Field(i), m.naming)
+
+		if _, err := parseIndexes(arrayElem); err != nil {
+			return err
+		}
+
+		if _, ok := m.cols[colName]; ok {
+			continue
+		}
+
+		_, edge := arrayElem.FieldByName("From")
+
+		m.cols[colName] = &memoryCollection{
+			edge:   edge,
+			docs:   map[string]map[string]interface{}{},
+			seeded: map[string]bool{},
+		}
+	}
+
+	return nil
+}
+
+func (m *MemoryManager) Drop(rootUser, rootPassword string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.created {
+		return errors.New("database not found")
+	}
+
+	m.created = false
+	m.cols = map[string]*memoryCollection{}
+	m.applied = nil
+
+	return nil
+}
+
+func (m *MemoryManager) LoadDistantSeed() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	local := reflect.ValueOf(m.localSeed)
+	distant := reflect.ValueOf(m.distantSeed)
+
+	if local.Kind() != reflect.Ptr || distant.Kind() != reflect.Ptr {
+		return errors.New("invalid seed type: not a pointer")
+	}
+
+	local = local.Elem()
+	distant = distant.Elem()
+
+	if local.Kind() != reflect.Struct || distant.Kind() != reflect.Struct {
+		return errors.New("invalid seed type: not a struct")
+	}
+
+	drift := &SeedDriftError{}
+
+	for i := 0; i < local.NumField(); i++ {
+		localField := local.Field(i)
+		distantField := distant.Field(i)
+
+		if localField.Kind() != reflect.Slice {
+			continue
+		}
+
+		arrayElem := localField.Type().Elem()
+
+		if arrayElem.Kind() != reflect.Struct {
+			return errors.New("invalid seed field type: not a struct")
+		}
+
+		colName := collectionName(local.Type().Field(i), m.naming)
+
+		col, ok := m.cols[colName]
+		if !ok {
+			return fmt.Errorf("collection not found: %s", colName)
+		}
+
+		seed, err := toDocuments(localField.Interface())
+		if err != nil {
+			return err
+		}
+
+		keyOnly := local.Type().Field(i).Tag.Get("check") == "keyOnly"
+		matching := []map[string]interface{}{}
+
+		for _, y := range seed {
+			key, _ := y["_key"].(string)
+
+			x, ok := col.docs[key]
+			if !ok || (!keyOnly && !matchesDocument(x, y)) {
+				continue
+			}
+
+			matching = append(matching, x)
+		}
+
+		raw, err := json.Marshal(matching)
+		if err != nil {
+			return err
+		}
+
+		json.Unmarshal(raw, distantField.Addr().Interface())
+
+		if distantField.Len() < localField.Len() {
+			colDrift, err := seedDrift(colName, localField.Interface(), keyOnly, col.docs)
+			if err != nil {
+				return err
+			}
+
+			drift.Collections = append(drift.Collections, *colDrift)
+		}
+	}
+
+	if len(drift.Collections) > 0 {
+		return drift
+	}
+
+	return nil
+}
+
+func (m *MemoryManager) SyncSeeds() error {
+	if err := m.syncSeeds(); err != nil {
+		return err
+	}
+
+	if err := m.LoadDistantSeed(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *MemoryManager) syncSeeds() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	local := reflect.ValueOf(m.localSeed)
+
+	if local.Kind() != reflect.Ptr {
+		return errors.New("invalid seed type: not a pointer")
+	}
+
+	local = local.Elem()
+
+	if local.Kind() != reflect.Struct {
+		return errors.New("invalid seed type: not a struct")
+	}
+
+	if err := ValidateSeed(m.localSeed, m.naming); err != nil {
+		return err
+	}
+
+	for i := 0; i < local.NumField(); i++ {
+		field := local.Field(i)
+
+		if field.Kind() != reflect.Slice {
+			continue
+		}
+
+		if field.Len() == 0 && !hasTagOption(local.Type().Field(i).Tag.Get("seed"), "prune") {
+			continue
+		}
+
+		arrayElem := field.Type().Elem()
+
+		if arrayElem.Kind() != reflect.Struct {
+			return errors.New("invalid seed field type: not a struct")
+		}
+
+		colName := collectionName(local.Type().Field(i), m.naming)
+
+		col, ok := m.cols[colName]
+		if !ok {
+			return fmt.Errorf("collection not found: %s", colName)
+		}
+
+		seed, err := toDocuments(field.Interface())
+		if err != nil {
+			return err
+		}
+
+		forceUpdate := hasTagOption(local.Type().Field(i).Tag.Get("seed"), "forceUpdate")
+		inserted := map[string]bool{}
+
+		for _, x := range seed {
+			key, _ := x["_key"].(string)
+			if key == "" {
+				continue
+			}
+
+			if col.edge && (x["_from"] == nil || x["_to"] == nil) {
+				if forceUpdate {
+					return fmt.Errorf("invalid edge %s in %s: missing _from or _to", key, colName)
+				}
+				continue
+			}
+
+			_, exists := col.docs[key]
+			if exists && !forceUpdate {
+				continue
+			}
+
+			delete(x, "_rev")
+			x["_id"] = colName + "/" + key
+			col.docs[key] = x
+
+			if !exists {
+				inserted[key] = true
+			}
+		}
+
+		seeded := map[string]bool{}
+
+		for _, x := range seed {
+			if key, _ := x["_key"].(string); key != "" && (inserted[key] || col.seeded[key]) {
+				seeded[key] = true
+			}
+		}
+
+		if hasTagOption(local.Type().Field(i).Tag.Get("seed"), "prune") {
+			for key := range col.seeded {
+				if !seeded[key] {
+					delete(col.docs, key)
+				}
+			}
+		}
+
+		col.seeded = seeded
+	}
+
+	return nil
+}
+
+// toDocuments converts a seed slice to generic documents, as stored by the database.
+func toDocuments(seed interface{}) ([]map[string]interface{}, error) {
+	raw, err := json.Marshal(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := []map[string]interface{}{}
+
+	if err := json.Unmarshal(raw, &docs); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+// matchesDocument reports whether all the fields of the seed document y
+// but "_id" and "_rev" are equal in the distant document x.
+func matchesDocument(x, y map[string]interface{}) bool {
+	for name, value := range y {
+		if name == "_id" || name == "_rev" {
+			continue
+		}
+
+		if !reflect.DeepEqual(x[name], value) {
+			return false
+		}
+	}
+
+	return true
+}
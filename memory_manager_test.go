@@ -0,0 +1,210 @@
+package snakepit
+
+import "testing"
+
+type testUser struct {
+	Key  string `json:"_key,omitempty"`
+	Name string `json:"name"`
+}
+
+type testFriend struct {
+	Key  string `json:"_key,omitempty"`
+	From string `json:"_from"`
+	To   string `json:"_to"`
+}
+
+type testSeed struct {
+	Users   []testUser   `seed:"forceUpdate"`
+	Groups  []testUser   `check:"keyOnly"`
+	Friends []testFriend `graph:"social" from:"users" to:"users"`
+}
+
+func newTestMemoryManager(t *testing.T, local *testSeed) (*MemoryManager, *testSeed) {
+	distant := &testSeed{}
+	m := NewMemoryManager(local, distant)
+
+	if err := m.Create("root", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	return m, distant
+}
+
+func TestMemoryManagerCreateMigrate(t *testing.T) {
+	m := NewMemoryManager(&testSeed{}, &testSeed{})
+
+	if err := m.Migrate(); err == nil {
+		t.Error("migrating a missing database should fail")
+	}
+
+	if err := m.Drop("root", ""); err == nil {
+		t.Error("dropping a missing database should fail")
+	}
+
+	m, _ = newTestMemoryManager(t, &testSeed{})
+
+	for _, name := range []string{"users", "groups", "friends"} {
+		if _, ok := m.cols[name]; !ok {
+			t.Errorf("collection %s not created", name)
+		}
+	}
+
+	if !m.cols["friends"].edge || m.cols["users"].edge {
+		t.Error("edge collections should be detected from their From field")
+	}
+}
+
+func TestMemoryManagerSyncSeeds(t *testing.T) {
+	local := &testSeed{
+		Users:   []testUser{{Key: "alice", Name: "Alice"}, {Key: "bob", Name: "Bob"}},
+		Groups:  []testUser{{Key: "admins", Name: "Admins"}},
+		Friends: []testFriend{{Key: "ab", From: "users/alice", To: "users/bob"}},
+	}
+	m, distant := newTestMemoryManager(t, local)
+
+	if err := m.SyncSeeds(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(distant.Users) != 2 || len(distant.Groups) != 1 || len(distant.Friends) != 1 {
+		t.Fatalf("unexpected distant seed: %+v", distant)
+	}
+
+	// Like the INSERT ignoreErrors of the ArangoDBManager, existing documents are kept...
+	m.cols["groups"].docs["admins"]["name"] = "Changed"
+	// ...unless the collection is forceUpdate, replaced like with an UPSERT.
+	m.cols["users"].docs["alice"]["name"] = "Changed"
+
+	if err := m.SyncSeeds(); err != nil {
+		t.Fatal(err)
+	}
+
+	if name := m.cols["users"].docs["alice"]["name"]; name != "Alice" {
+		t.Errorf("forceUpdate document not replaced: %v", name)
+	}
+	if name := m.cols["groups"].docs["admins"]["name"]; name != "Changed" {
+		t.Errorf("existing document replaced: %v", name)
+	}
+}
+
+func TestMemoryManagerDrift(t *testing.T) {
+	local := &testSeed{
+		Users: []testUser{{Key: "alice", Name: "Alice"}},
+	}
+	m, _ := newTestMemoryManager(t, local)
+
+	if err := m.SyncSeeds(); err != nil {
+		t.Fatal(err)
+	}
+
+	local.Users = append(local.Users, testUser{Key: "bob", Name: "Bob"})
+	m.cols["users"].docs["alice"]["name"] = "Changed"
+
+	err := m.LoadDistantSeed()
+
+	drift, ok := err.(*SeedDriftError)
+	if !ok {
+		t.Fatalf("expected a seed drift, got %v", err)
+	}
+
+	if len(drift.Collections) != 1 || drift.Collections[0].Name != "users" {
+		t.Fatalf("unexpected drift: %+v", drift)
+	}
+
+	col := drift.Collections[0]
+
+	if len(col.Missing) != 1 || col.Missing[0] != "bob" {
+		t.Errorf("unexpected missing documents: %v", col.Missing)
+	}
+	if len(col.Changed) != 1 || col.Changed[0].Key != "alice" {
+		t.Errorf("unexpected changed documents: %+v", col.Changed)
+	}
+}
+
+func TestMemoryManagerMigrations(t *testing.T) {
+	ran := []string{}
+
+	m := NewMemoryManager(&testSeed{}, &testSeed{})
+	m.RegisterMigrations(
+		Migration{
+			Version: 2,
+			Name:    "second",
+			Up:      func(DatabaseManager) error { ran = append(ran, "up2"); return nil },
+			Down:    func(DatabaseManager) error { ran = append(ran, "down2"); return nil },
+		},
+		Migration{
+			Version: 1,
+			Name:    "first",
+			Up: func(dm DatabaseManager) error {
+				ran = append(ran, "up1")
+				// Migrations receive the manager running them.
+				return dm.LoadDistantSeed()
+			},
+		},
+	)
+
+	if err := m.Create("root", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Rollback(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Rollback(1); err == nil || err.Error() != "irreversible migration: 1" {
+		t.Errorf("unexpected rollback error: %v", err)
+	}
+
+	if got := len(ran); got != 3 || ran[0] != "up1" || ran[1] != "up2" || ran[2] != "down2" {
+		t.Errorf("unexpected migration runs: %v", ran)
+	}
+}
+
+func TestMemoryManagerPruneKeepsUserDocuments(t *testing.T) {
+	type pruneSeed struct {
+		Countries []testUser `seed:"prune"`
+	}
+
+	local := &pruneSeed{Countries: []testUser{{Key: "fr", Name: "France"}}}
+	m := NewMemoryManager(local, &pruneSeed{})
+
+	if err := m.Create("root", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SyncSeeds(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A user creates a document, later added to the seed then removed from it.
+	m.cols["countries"].docs["us"] = map[string]interface{}{"_key": "us", "name": "Created by a user"}
+
+	local.Countries = append(local.Countries, testUser{Key: "us", Name: "United States"})
+	if err := m.SyncSeeds(); err == nil {
+		t.Fatal("the user document should drift from the seed")
+	}
+
+	local.Countries = local.Countries[:0]
+	if err := m.SyncSeeds(); err != nil {
+		t.Fatal(err)
+	}
+
+	docs := m.cols["countries"].docs
+
+	if _, ok := docs["us"]; !ok {
+		t.Error("user document pruned")
+	}
+	if _, ok := docs["fr"]; ok {
+		t.Error("seeded document not pruned")
+	}
+}
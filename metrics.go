@@ -0,0 +1,258 @@
+package snakepit
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pressly/chi"
+	"golang.org/x/net/context"
+)
+
+const contextRoute CtxKey = "route"
+
+var (
+	// DefBuckets are the default latency histogram buckets, in seconds.
+	DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// SizeBuckets are the default response size histogram buckets, in bytes.
+	SizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+type metricFamily struct {
+	help    string
+	kind    string
+	buckets []float64
+	series  map[string]*metricSeries
+}
+
+type metricSeries struct {
+	value  float64
+	counts []uint64
+	sum    float64
+}
+
+// Metrics records the HTTP, JSON and database metrics of the service, and its
+// middleware exposes them in the Prometheus text format on the configured path.
+type Metrics struct {
+	mutex    sync.Mutex
+	path     string
+	families map[string]*metricFamily
+}
+
+func NewMetrics(path string) *Metrics {
+	m := &Metrics{
+		path:     path,
+		families: map[string]*metricFamily{},
+	}
+
+	m.register("http_requests_total", "counter", "Number of HTTP requests served.", nil)
+	m.register("http_requests_in_flight", "gauge", "Number of HTTP requests being served.", nil)
+	m.register("http_request_duration_seconds", "histogram", "Latency of the HTTP requests.", DefBuckets)
+	m.register("http_response_size_bytes", "histogram", "Size of the HTTP responses.", SizeBuckets)
+	m.register("json_duration_seconds", "histogram", "Duration of the JSON marshalling and unmarshalling.", DefBuckets)
+	m.register("arangodb_query_duration_seconds", "histogram", "Duration of the ArangoDB requests.", DefBuckets)
+	m.register("arangodb_query_errors_total", "counter", "Number of failed ArangoDB requests.", nil)
+
+	return m
+}
+
+// Route returns a middleware labelling the metrics of the requests it serves with
+// the given route pattern. Unlabelled requests are recorded under the "other" route,
+// so that the URL paths never leak into the metric labels.
+func (m *Metrics) Route(pattern string) func(next chi.Handler) chi.Handler {
+	return func(next chi.Handler) chi.Handler {
+		return chi.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if route, ok := ctx.Value(contextRoute).(*string); ok && route != nil {
+				*route = pattern
+			}
+			next.ServeHTTPC(ctx, w, r)
+		})
+	}
+}
+
+func (m *Metrics) Middleware(next chi.Handler) chi.Handler {
+	return chi.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == m.path {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+			m.Write(w)
+			return
+		}
+
+		start := time.Now()
+
+		m.add("http_requests_in_flight", "", 1)
+		defer m.add("http_requests_in_flight", "", -1)
+
+		// The route pointer may already be shared with the Logger.
+		route, ok := ctx.Value(contextRoute).(*string)
+		if !ok || route == nil {
+			route = new(string)
+			ctx = context.WithValue(ctx, contextRoute, route)
+		}
+
+		proxy := wrapWriter(w)
+		next.ServeHTTPC(ctx, proxy, r)
+		proxy.maybeWriteHeader()
+
+		routeLabel := *route
+		if routeLabel == "" {
+			routeLabel = "other"
+		}
+
+		labels := metricLabels("method", r.Method, "route", routeLabel)
+
+		m.add("http_requests_total", metricLabels("method", r.Method, "route", routeLabel, "status", strconv.Itoa(proxy.status())), 1)
+		m.observe("http_request_duration_seconds", labels, time.Since(start).Seconds())
+		m.observe("http_response_size_bytes", labels, float64(proxy.bytesWritten()))
+	})
+}
+
+// ObserveJSON records the duration of a JSON operation. It is a no-op on a nil Metrics.
+func (m *Metrics) ObserveJSON(op, name string, start time.Time) {
+	if m != nil {
+		m.observe("json_duration_seconds", metricLabels("op", op, "name", name), time.Since(start).Seconds())
+	}
+}
+
+// ObserveQuery records the duration and the failure of a database request.
+// It is a no-op on a nil Metrics.
+func (m *Metrics) ObserveQuery(description string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	labels := metricLabels("description", description)
+
+	m.observe("arangodb_query_duration_seconds", labels, time.Since(start).Seconds())
+	if err != nil {
+		m.add("arangodb_query_errors_total", labels, 1)
+	}
+}
+
+func (m *Metrics) register(name, kind, help string, buckets []float64) {
+	m.families[name] = &metricFamily{
+		help:    help,
+		kind:    kind,
+		buckets: buckets,
+		series:  map[string]*metricSeries{},
+	}
+}
+
+func (m *Metrics) get(name, labels string) *metricSeries {
+	family := m.families[name]
+
+	series, ok := family.series[labels]
+	if !ok {
+		series = &metricSeries{counts: make([]uint64, len(family.buckets))}
+		family.series[labels] = series
+	}
+
+	return series
+}
+
+func (m *Metrics) add(name, labels string, delta float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.get(name, labels).value += delta
+}
+
+func (m *Metrics) observe(name, labels string, value float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	series := m.get(name, labels)
+	buckets := m.families[name].buckets
+
+	for i := range buckets {
+		if value <= buckets[i] {
+			series.counts[i]++
+		}
+	}
+
+	series.value++
+	series.sum += value
+}
+
+// Write prints all the metrics in the Prometheus text format.
+func (m *Metrics) Write(w io.Writer) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		family := m.families[name]
+
+		fmt.Fprintf(w, "# HELP %s %s\n", name, family.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, family.kind)
+
+		labelSets := make([]string, 0, len(family.series))
+		for labels := range family.series {
+			labelSets = append(labelSets, labels)
+		}
+		sort.Strings(labelSets)
+
+		for _, labels := range labelSets {
+			series := family.series[labels]
+
+			if family.kind != "histogram" {
+				fmt.Fprintf(w, "%s%s %s\n", name, braced(labels), formatFloat(series.value))
+				continue
+			}
+
+			for i, bound := range family.buckets {
+				fmt.Fprintf(w, "%s_bucket%s %d\n", name, braced(joinLabels(labels, metricLabels("le", formatFloat(bound)))), series.counts[i])
+			}
+			fmt.Fprintf(w, "%s_bucket%s %s\n", name, braced(joinLabels(labels, `le="+Inf"`)), formatFloat(series.value))
+			fmt.Fprintf(w, "%s_sum%s %s\n", name, braced(labels), formatFloat(series.sum))
+			fmt.Fprintf(w, "%s_count%s %s\n", name, braced(labels), formatFloat(series.value))
+		}
+	}
+}
+
+// metricLabels formats label name and value pairs.
+func metricLabels(pairs ...string) string {
+	labels := make([]string, 0, len(pairs)/2)
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(pairs[i+1])
+		labels = append(labels, pairs[i]+`="`+value+`"`)
+	}
+
+	return strings.Join(labels, ",")
+}
+
+func joinLabels(a, b string) string {
+	if a == "" {
+		return b
+	}
+
+	return a + "," + b
+}
+
+func braced(labels string) string {
+	if labels == "" {
+		return ""
+	}
+
+	return "{" + labels + "}"
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
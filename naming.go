@@ -0,0 +1,89 @@
+package snakepit
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy derives a collection name from the base name of a seed field,
+// which is its `collection` tag if any, or the field name.
+type NamingStrategy func(name string) string
+
+// CamelCase names the collections in lowerCamelCase. It is the default strategy.
+func CamelCase(name string) string {
+	if name == "" {
+		return name
+	}
+
+	return strings.ToLower(name[0:1]) + name[1:]
+}
+
+// SnakeCase names the collections in snake_case.
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	out := make([]rune, 0, len(runes)+4)
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && runes[i-1] != '_' {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				out = append(out, '_')
+			}
+		}
+
+		out = append(out, unicode.ToLower(r))
+	}
+
+	return string(out)
+}
+
+// Prefixed returns a strategy prefixing the names given by another one,
+// for example to isolate the collections of a tenant or an environment.
+func Prefixed(prefix string, strategy NamingStrategy) NamingStrategy {
+	return func(name string) string {
+		return prefix + strategy(name)
+	}
+}
+
+// NewNamingStrategy returns the strategy named "camelCase" or "snakeCase",
+// prefixed if prefix is not empty. It allows selecting the strategy from the config.
+func NewNamingStrategy(name, prefix string) (NamingStrategy, error) {
+	var strategy NamingStrategy
+
+	switch name {
+	case "", "camelCase":
+		strategy = CamelCase
+	case "snakeCase":
+		strategy = SnakeCase
+	default:
+		return nil, fmt.Errorf("unknown naming strategy: %s", name)
+	}
+
+	if prefix != "" {
+		strategy = Prefixed(prefix, strategy)
+	}
+
+	return strategy, nil
+}
+
+// collectionName returns the name of the collection of a seed field.
+func collectionName(field reflect.StructField, naming NamingStrategy) string {
+	name := field.Tag.Get("collection")
+	if name == "" {
+		name = field.Name
+	}
+
+	return applyNaming(naming, name)
+}
+
+func applyNaming(naming NamingStrategy, name string) string {
+	if naming == nil {
+		naming = CamelCase
+	}
+
+	return naming(name)
+}
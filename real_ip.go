@@ -0,0 +1,195 @@
+package snakepit
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pressly/chi"
+	"golang.org/x/net/context"
+)
+
+const contextRealIP CtxKey = "realIP"
+
+var (
+	xForwardedFor = http.CanonicalHeaderKey("X-Forwarded-For")
+	xRealIP       = http.CanonicalHeaderKey("X-Real-IP")
+	forwarded     = http.CanonicalHeaderKey("Forwarded")
+)
+
+// GetRealIP returns the client IP from the given context if one is present.
+func GetRealIP(ctx context.Context) (string, error) {
+	if ctx == nil {
+		return "", errors.New("nil context")
+	}
+
+	ip, ok := ctx.Value(contextRealIP).(string)
+	if !ok {
+		return "", errors.New("unexpected type")
+	}
+
+	if len(ip) == 0 {
+		return "", errors.New("empty value in context")
+	}
+
+	return ip, nil
+}
+
+// RealIP is a middleware that injects the client IP into the context of each request.
+// Only the header set by the trusted proxies is read, and only when the request comes
+// from one of them. Its hops are walked from right to left, skipping the trusted proxies,
+// and the first other address is the client. r.RemoteAddr is left untouched.
+type RealIP struct {
+	trusted []*net.IPNet
+	header  string
+}
+
+// RealIPOption configures the RealIP middleware.
+type RealIPOption func(r *RealIP)
+
+// TrustedHeader sets the header the trusted proxies set: "X-Forwarded-For" (default),
+// the RFC 7239 "Forwarded" or "X-Real-IP". The other ones are ignored, as a client
+// can send them through the proxies.
+func TrustedHeader(header string) RealIPOption {
+	return func(r *RealIP) {
+		r.header = http.CanonicalHeaderKey(header)
+	}
+}
+
+// NewRealIP returns the middleware trusting the given proxy CIDRs, such as "10.0.0.0/8".
+// Single IPs are accepted too.
+func NewRealIP(trustedProxies []string, options ...RealIPOption) (func(next chi.Handler) chi.Handler, error) {
+	realIP, err := newRealIP(trustedProxies, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return realIP.middleware, nil
+}
+
+func newRealIP(trustedProxies []string, options ...RealIPOption) (*RealIP, error) {
+	realIP := &RealIP{header: xForwardedFor}
+
+	for _, option := range options {
+		option(realIP)
+	}
+
+	switch realIP.header {
+	case xForwardedFor, forwarded, xRealIP:
+	default:
+		return nil, fmt.Errorf("unsupported forwarding header: %s", realIP.header)
+	}
+
+	for _, cidr := range trustedProxies {
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+
+		realIP.trusted = append(realIP.trusted, network)
+	}
+
+	return realIP, nil
+}
+
+func (rip *RealIP) middleware(next chi.Handler) chi.Handler {
+	return chi.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if ip := rip.clientIP(r); ip != "" {
+			ctx = context.WithValue(ctx, contextRealIP, ip)
+		}
+		next.ServeHTTPC(ctx, w, r)
+	})
+}
+
+func (rip *RealIP) clientIP(r *http.Request) string {
+	remote := parseHop(r.RemoteAddr)
+	if remote == nil {
+		return ""
+	}
+
+	if !rip.isTrusted(remote) {
+		return remote.String()
+	}
+
+	var hops []string
+
+	switch values := r.Header[rip.header]; rip.header {
+	case forwarded:
+		hops = forwardedFor(values)
+	case xForwardedFor:
+		if len(values) > 0 {
+			hops = strings.Split(strings.Join(values, ","), ",")
+		}
+	case xRealIP:
+		if len(values) > 0 {
+			hops = values[len(values)-1:]
+		}
+	}
+
+	client := remote
+
+	for i := len(hops) - 1; i >= 0 && rip.isTrusted(client); i-- {
+		ip := parseHop(hops[i])
+		if ip == nil {
+			// A malformed or obfuscated hop can not be walked through.
+			break
+		}
+
+		client = ip
+	}
+
+	return client.String()
+}
+
+func (rip *RealIP) isTrusted(ip net.IP) bool {
+	for _, network := range rip.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forwardedFor returns the "for" parameters of the Forwarded header elements, in order.
+func forwardedFor(values []string) []string {
+	hops := []string{}
+
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			hop := ""
+
+			for _, pair := range strings.Split(element, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+					hop = strings.Trim(kv[1], `"`)
+				}
+			}
+
+			hops = append(hops, hop)
+		}
+	}
+
+	return hops
+}
+
+// parseHop parses an IP, optionally bracketed and followed by a port.
+func parseHop(hop string) net.IP {
+	hop = strings.TrimSpace(hop)
+
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	}
+
+	return net.ParseIP(strings.Trim(hop, "[]"))
+}
@@ -0,0 +1,126 @@
+package snakepit
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRealIPClientIP(t *testing.T) {
+	cases := []struct {
+		name    string
+		header  string
+		remote  string
+		headers map[string][]string
+		client  string
+	}{
+		{
+			name:   "untrusted remote",
+			remote: "203.0.113.7:1234",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"198.51.100.1"},
+			},
+			client: "203.0.113.7",
+		},
+		{
+			name:   "spoofed Forwarded",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"Forwarded":       {"for=198.51.100.1"},
+				"X-Forwarded-For": {"203.0.113.7"},
+			},
+			client: "203.0.113.7",
+		},
+		{
+			name:   "spoofed X-Forwarded-For",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"198.51.100.1, 203.0.113.7"},
+			},
+			client: "203.0.113.7",
+		},
+		{
+			name:   "spoofed X-Forwarded-For through Forwarded proxies",
+			header: "Forwarded",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"Forwarded":       {`for=198.51.100.1, for="203.0.113.7:4711"`},
+				"X-Forwarded-For": {"198.51.100.2"},
+				"X-Real-Ip":       {"198.51.100.3"},
+			},
+			client: "203.0.113.7",
+		},
+		{
+			name:   "chain of trusted proxies",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"198.51.100.1, 203.0.113.7", "10.0.0.3, 10.0.0.2"},
+			},
+			client: "203.0.113.7",
+		},
+		{
+			name:   "chain of trusted Forwarded proxies",
+			header: "Forwarded",
+			remote: "[fd00::1]:1234",
+			headers: map[string][]string{
+				"Forwarded": {`for=198.51.100.1, for="[2001:db8::7]", for=10.0.0.2;proto=https, for="[fd00::2]:8080"`},
+			},
+			client: "2001:db8::7",
+		},
+		{
+			name:   "only trusted proxies",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"},
+			},
+			client: "10.0.0.3",
+		},
+		{
+			name:   "obfuscated hop",
+			header: "Forwarded",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"Forwarded": {"for=198.51.100.1, for=_hidden, for=10.0.0.2"},
+			},
+			client: "10.0.0.2",
+		},
+		{
+			name:   "X-Real-IP",
+			header: "X-Real-IP",
+			remote: "10.0.0.1:1234",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"198.51.100.1"},
+				"X-Real-Ip":       {"203.0.113.7"},
+			},
+			client: "203.0.113.7",
+		},
+		{
+			name:   "missing header",
+			remote: "10.0.0.1:1234",
+			client: "10.0.0.1",
+		},
+	}
+
+	for _, c := range cases {
+		options := []RealIPOption{}
+		if c.header != "" {
+			options = append(options, TrustedHeader(c.header))
+		}
+
+		rip, err := newRealIP([]string{"10.0.0.0/8", "fd00::/8"}, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := &http.Request{RemoteAddr: c.remote, Header: http.Header(c.headers)}
+
+		if client := rip.clientIP(r); client != c.client {
+			t.Errorf("%s: got %q, want %q", c.name, client, c.client)
+		}
+	}
+}
+
+func TestNewRealIPUnsupportedHeader(t *testing.T) {
+	if _, err := NewRealIP([]string{"10.0.0.1"}, TrustedHeader("X-Client-IP")); err == nil {
+		t.Error("expected an error")
+	}
+}
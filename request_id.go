@@ -6,12 +6,16 @@ package snakepit
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 	"sync/atomic"
+	"time"
 
 	"github.com/pressly/chi"
 	"golang.org/x/net/context"
@@ -77,22 +81,139 @@ func init() {
 	prefix = fmt.Sprintf("%s/%s", hostname, b64[0:10])
 }
 
-// RequestID is a middleware that injects a request ID into the context of each
-// request. A request ID is a string of the form "host.example.com/random-0001",
+// IDGenerator returns a new request ID.
+type IDGenerator func() string
+
+// CounterID generates IDs of the form "host.example.com/random-0001",
 // where "random" is a base62 random string that uniquely identifies this go
 // process, and where the last number is an atomically incremented request
 // counter.
-type RequestID struct{}
+func CounterID() string {
+	myid := atomic.AddUint64(&reqid, 1)
+	return fmt.Sprintf("%s-%06d", prefix, myid)
+}
+
+// UUIDv4 generates random RFC 4122 version 4 UUIDs.
+func UUIDv4() string {
+	var buf [16]byte
+	rand.Read(buf[:])
+
+	buf[6] = buf[6]&0x0f | 0x40
+	buf[8] = buf[8]&0x3f | 0x80
+
+	h := hex.EncodeToString(buf[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// ULID generates lexicographically sortable identifiers: a millisecond timestamp
+// followed by 80 random bits, encoded in Crockford's base32.
+func ULID() string {
+	const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+	var buf [16]byte
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	for i := 0; i < 6; i++ {
+		buf[i] = byte(ms >> uint(40-8*i))
+	}
+	rand.Read(buf[6:])
+
+	n := new(big.Int).SetBytes(buf[:])
+	mask := big.NewInt(31)
+	id := make([]byte, 26)
+
+	for i := len(id) - 1; i >= 0; i-- {
+		id[i] = alphabet[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 5)
+	}
+
+	return string(id)
+}
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:/+=@-]+$`)
+
+// RequestIDOption configures the RequestID middleware.
+type RequestIDOption func(r *RequestID)
+
+// TrustHeaders sets the inbound headers whose ID is reused, checked in order.
+// They should only be trusted when set by a gateway.
+func TrustHeaders(headers ...string) RequestIDOption {
+	return func(r *RequestID) {
+		r.trusted = headers
+	}
+}
+
+// EchoHeader sets the response header carrying the effective ID ("" to disable).
+func EchoHeader(header string) RequestIDOption {
+	return func(r *RequestID) {
+		r.echo = header
+	}
+}
+
+// MaxIDLength sets the maximum length of an inbound ID.
+func MaxIDLength(length int) RequestIDOption {
+	return func(r *RequestID) {
+		r.maxLength = length
+	}
+}
+
+// GenerateWith sets the generator of the IDs of the requests without valid inbound ID.
+// A nil generator falls back to CounterID.
+func GenerateWith(generator IDGenerator) RequestIDOption {
+	return func(r *RequestID) {
+		r.generator = generator
+	}
+}
+
+// RequestID is a middleware that injects a request ID into the context of each
+// request. The ID is read from the trusted inbound headers if one is valid,
+// that is to say not longer than the maximum length and only made of letters,
+// digits and "._:/+=@-". Otherwise a new one is generated, by default with CounterID.
+// The effective ID is echoed in the response, by default in "X-Request-ID".
+type RequestID struct {
+	trusted   []string
+	echo      string
+	maxLength int
+	generator IDGenerator
+}
+
+func NewRequestID(options ...RequestIDOption) func(next chi.Handler) chi.Handler {
+	requestID := &RequestID{
+		echo:      "X-Request-ID",
+		maxLength: 128,
+		generator: CounterID,
+	}
+
+	for _, option := range options {
+		option(requestID)
+	}
+
+	if requestID.generator == nil {
+		requestID.generator = CounterID
+	}
 
-func NewRequestID() func(next chi.Handler) chi.Handler {
-	requestID := &RequestID{}
 	return requestID.middleware
 }
 
-func (r *RequestID) middleware(next chi.Handler) chi.Handler {
+func (rid *RequestID) middleware(next chi.Handler) chi.Handler {
 	return chi.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-		myid := atomic.AddUint64(&reqid, 1)
-		ctx = context.WithValue(ctx, contextRequestID, fmt.Sprintf("%s-%06d", prefix, myid))
+		id := ""
+
+		for _, header := range rid.trusted {
+			if v := r.Header.Get(header); v != "" && len(v) <= rid.maxLength && validRequestID.MatchString(v) {
+				id = v
+				break
+			}
+		}
+
+		if id == "" {
+			id = rid.generator()
+		}
+
+		if rid.echo != "" {
+			w.Header().Set(rid.echo, id)
+		}
+
+		ctx = context.WithValue(ctx, contextRequestID, id)
 		next.ServeHTTPC(ctx, w, r)
 	})
 }
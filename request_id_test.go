@@ -0,0 +1,140 @@
+package snakepit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pressly/chi"
+	"golang.org/x/net/context"
+)
+
+func TestRequestIDInbound(t *testing.T) {
+	generated := func() string { return "generated" }
+
+	cases := []struct {
+		name    string
+		options []RequestIDOption
+		headers map[string]string
+		id      string
+		echo    string
+	}{
+		{
+			name:    "untrusted header",
+			headers: map[string]string{"X-Request-ID": "abc"},
+			id:      "generated",
+			echo:    "X-Request-ID",
+		},
+		{
+			name:    "trusted header",
+			options: []RequestIDOption{TrustHeaders("X-Request-ID")},
+			headers: map[string]string{"X-Request-ID": "gw-1:abc/def+g=@h._-"},
+			id:      "gw-1:abc/def+g=@h._-",
+			echo:    "X-Request-ID",
+		},
+		{
+			name:    "trusted headers in order",
+			options: []RequestIDOption{TrustHeaders("X-Amzn-Trace-Id", "X-Request-ID")},
+			headers: map[string]string{"X-Request-ID": "second", "X-Amzn-Trace-Id": "Root=1-5759e988"},
+			id:      "Root=1-5759e988",
+			echo:    "X-Request-ID",
+		},
+		{
+			name:    "invalid first trusted header",
+			options: []RequestIDOption{TrustHeaders("X-Amzn-Trace-Id", "X-Request-ID")},
+			headers: map[string]string{"X-Request-ID": "second", "X-Amzn-Trace-Id": "Root=1;Parent=2"},
+			id:      "second",
+			echo:    "X-Request-ID",
+		},
+		{
+			name:    "invalid charset",
+			options: []RequestIDOption{TrustHeaders("X-Request-ID")},
+			headers: map[string]string{"X-Request-ID": "abc\r\nX-Injected: 1"},
+			id:      "generated",
+			echo:    "X-Request-ID",
+		},
+		{
+			name:    "space",
+			options: []RequestIDOption{TrustHeaders("X-Request-ID")},
+			headers: map[string]string{"X-Request-ID": "abc def"},
+			id:      "generated",
+			echo:    "X-Request-ID",
+		},
+		{
+			name:    "max length",
+			options: []RequestIDOption{TrustHeaders("X-Request-ID")},
+			headers: map[string]string{"X-Request-ID": strings.Repeat("a", 128)},
+			id:      strings.Repeat("a", 128),
+			echo:    "X-Request-ID",
+		},
+		{
+			name:    "over-long",
+			options: []RequestIDOption{TrustHeaders("X-Request-ID")},
+			headers: map[string]string{"X-Request-ID": strings.Repeat("a", 129)},
+			id:      "generated",
+			echo:    "X-Request-ID",
+		},
+		{
+			name:    "custom max length",
+			options: []RequestIDOption{TrustHeaders("X-Request-ID"), MaxIDLength(4)},
+			headers: map[string]string{"X-Request-ID": "abcde"},
+			id:      "generated",
+			echo:    "X-Request-ID",
+		},
+		{
+			name:    "custom echo header",
+			options: []RequestIDOption{TrustHeaders("X-Request-ID"), EchoHeader("X-Correlation-ID")},
+			headers: map[string]string{"X-Request-ID": "abc"},
+			id:      "abc",
+			echo:    "X-Correlation-ID",
+		},
+		{
+			name:    "echo disabled",
+			options: []RequestIDOption{EchoHeader("")},
+			id:      "generated",
+			echo:    "",
+		},
+	}
+
+	for _, c := range cases {
+		var id string
+		options := append([]RequestIDOption{GenerateWith(generated)}, c.options...)
+		handler := NewRequestID(options...)(chi.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			id, _ = GetRequestID(ctx)
+		}))
+
+		r := httptest.NewRequest("GET", "/", nil)
+		for name, value := range c.headers {
+			r.Header[http.CanonicalHeaderKey(name)] = []string{value}
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTPC(context.Background(), w, r)
+
+		if id != c.id {
+			t.Errorf("%s: got ID %q, want %q", c.name, id, c.id)
+		}
+
+		if c.echo == "" {
+			if len(w.Header()) != 0 {
+				t.Errorf("%s: got response headers %v, want none", c.name, w.Header())
+			}
+		} else if echo := w.Header().Get(c.echo); echo != c.id {
+			t.Errorf("%s: got %s %q, want %q", c.name, c.echo, echo, c.id)
+		}
+	}
+}
+
+func TestRequestIDNilGenerator(t *testing.T) {
+	var id string
+	handler := NewRequestID(GenerateWith(nil))(chi.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		id, _ = GetRequestID(ctx)
+	}))
+
+	handler.ServeHTTPC(context.Background(), httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if !strings.HasPrefix(id, prefix) {
+		t.Errorf("got ID %q, want a CounterID one", id)
+	}
+}
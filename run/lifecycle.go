@@ -0,0 +1,108 @@
+package run
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Hook is a named lifecycle function, cancelled after its timeout (0 for infinite).
+type Hook struct {
+	Name    string
+	Timeout time.Duration
+	Func    func(ctx context.Context) error
+
+	// stops is the number of stop hooks registered before this start hook.
+	stops int
+}
+
+var (
+	hooksMutex sync.Mutex
+	startHooks []Hook
+	stopHooks  []Hook
+)
+
+// OnStart registers a hook run before the server starts listening.
+// Start hooks run in their registration order, and the first failure aborts the startup.
+func OnStart(name string, timeout time.Duration, fn func(ctx context.Context) error) {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	startHooks = append(startHooks, Hook{Name: name, Timeout: timeout, Func: fn, stops: len(stopHooks)})
+}
+
+// OnStop registers a hook run once the server is shut down, either on SIGINT/SIGTERM
+// or after a failure. Stop hooks run in the reverse of their registration order,
+// so that resources are released in the opposite order they were acquired.
+// A stop hook is expected to be registered after the start hook acquiring its resource:
+// when a start hook fails, only the stop hooks registered before it are run.
+func OnStop(name string, timeout time.Duration, fn func(ctx context.Context) error) {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+
+	stopHooks = append(stopHooks, Hook{Name: name, Timeout: timeout, Func: fn})
+}
+
+// start runs the start hooks. If one fails, the stop hooks registered before it
+// are run before returning the error.
+func start() error {
+	hooksMutex.Lock()
+	hooks := append([]Hook{}, startHooks...)
+	hooksMutex.Unlock()
+
+	for _, hook := range hooks {
+		Logger.Infof("Starting %s...", hook.Name)
+
+		if err := hook.run(); err != nil {
+			stopFirst(hook.stops)
+			return fmt.Errorf("start hook %s failed: %s", hook.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// stop runs all the stop hooks, logging the failures instead of interrupting the shutdown.
+func stop() {
+	hooksMutex.Lock()
+	n := len(stopHooks)
+	hooksMutex.Unlock()
+
+	stopFirst(n)
+}
+
+// stopFirst runs the first n registered stop hooks, in reverse order.
+func stopFirst(n int) {
+	hooksMutex.Lock()
+	hooks := append([]Hook{}, stopHooks[:n]...)
+	hooksMutex.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		Logger.Infof("Stopping %s...", hooks[i].Name)
+
+		if err := hooks[i].run(); err != nil {
+			Logger.Errorf("Stop hook %s failed: %s", hooks[i].Name, err)
+		}
+	}
+}
+
+func (h *Hook) run() error {
+	ctx, cancel := context.Background(), func() {}
+	if h.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+	}
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() { done <- h.Func(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,35 @@
+package run
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestStartFailureStopsOnlyStartedHooks(t *testing.T) {
+	defer func() { startHooks, stopHooks = nil, nil }()
+
+	calls := []string{}
+	hook := func(name string, err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	OnStart("db", 0, hook("open db", nil))
+	OnStop("db", 0, hook("close db", nil))
+	OnStart("cache", 0, hook("open cache", errors.New("unreachable")))
+	OnStop("cache", 0, hook("close cache", nil))
+
+	if err := start(); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	want := []string{"open db", "open cache", "close db"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got calls %q, want %q", calls, want)
+	}
+}
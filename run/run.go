@@ -1,6 +1,7 @@
 package run
 
 import (
+	"crypto/tls"
 	"errors"
 	"net/http"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/tylerb/graceful"
+	"golang.org/x/net/http2"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/solher/snakepit/root"
@@ -43,9 +45,64 @@ var Cmd = &cobra.Command{
 		port := root.Viper.GetInt(Port)
 		timeout := root.Viper.GetDuration(Timeout)
 
-		Logger.Infof("Listening on port %d.", port)
-		graceful.Run(":"+strconv.Itoa(port), timeout, appHandler)
-		return nil
+		var config *tls.Config
+
+		enabled, err := tlsEnabled(root.Viper)
+		if err != nil {
+			return err
+		}
+
+		if enabled {
+			if config, err = tlsConfig(root.Viper); err != nil {
+				return err
+			}
+		}
+
+		if err := start(); err != nil {
+			return err
+		}
+		defer stop()
+
+		srv := &graceful.Server{
+			Timeout: timeout,
+			LogFunc: Logger.Printf,
+			Server: &http.Server{
+				Addr:    ":" + strconv.Itoa(port),
+				Handler: appHandler,
+			},
+		}
+
+		if config == nil {
+			Logger.Infof("Listening on port %d.", port)
+			return srv.ListenAndServe()
+		}
+
+		srv.Server.TLSConfig = config
+		if err := http2.ConfigureServer(srv.Server, nil); err != nil {
+			return err
+		}
+
+		if redirectPort := root.Viper.GetInt(TLSRedirectPort); redirectPort != 0 {
+			redirect := &graceful.Server{
+				Timeout: timeout,
+				LogFunc: Logger.Printf,
+				Server: &http.Server{
+					Addr:    ":" + strconv.Itoa(redirectPort),
+					Handler: redirectHandler(port),
+				},
+			}
+			defer redirect.Stop(timeout)
+
+			go func() {
+				Logger.Infof("Redirecting port %d to HTTPS.", redirectPort)
+				if err := redirect.ListenAndServe(); err != nil {
+					Logger.Errorf("Redirect listener failed: %s", err)
+				}
+			}()
+		}
+
+		Logger.Infof("Listening on port %d (TLS).", port)
+		return srv.ListenAndServeTLSConfig(srv.Server.TLSConfig)
 	},
 }
 
@@ -65,4 +122,13 @@ func init() {
 
 	Cmd.PersistentFlags().Duration("timeout", 5*time.Second, "graceful shutdown timeout (0 for infinite)")
 	root.Viper.BindPFlag(Timeout, Cmd.PersistentFlags().Lookup("timeout"))
+
+	Cmd.PersistentFlags().String("tls-cert", "", "TLS certificate file (enables HTTPS and HTTP/2 with --tls-key)")
+	root.Viper.BindPFlag(TLSCert, Cmd.PersistentFlags().Lookup("tls-cert"))
+
+	Cmd.PersistentFlags().String("tls-key", "", "TLS private key file")
+	root.Viper.BindPFlag(TLSKey, Cmd.PersistentFlags().Lookup("tls-key"))
+
+	Cmd.PersistentFlags().Int("tls-redirect-port", 0, "port redirecting plain HTTP to HTTPS (0 to disable)")
+	root.Viper.BindPFlag(TLSRedirectPort, Cmd.PersistentFlags().Lookup("tls-redirect-port"))
 }
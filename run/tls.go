@@ -0,0 +1,116 @@
+package run
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	TLSCert         = "app.tls.cert"
+	TLSKey          = "app.tls.key"
+	TLSMinVersion   = "app.tls.minVersion"
+	TLSCipherSuites = "app.tls.cipherSuites"
+	TLSClientCA     = "app.tls.clientCA"
+	TLSRedirectPort = "app.tls.redirectPort"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsEnabled returns true when both a certificate and a key are configured.
+// A half-configured TLS setup is an error, never a silent fallback to plain HTTP.
+func tlsEnabled(v *viper.Viper) (bool, error) {
+	cert, key := v.GetString(TLSCert) != "", v.GetString(TLSKey) != ""
+
+	if cert != key {
+		return false, fmt.Errorf("incomplete TLS configuration: both %s and %s are required", TLSCert, TLSKey)
+	}
+
+	if !cert && (v.GetString(TLSClientCA) != "" || v.GetInt(TLSRedirectPort) != 0) {
+		return false, fmt.Errorf("incomplete TLS configuration: %s and %s require a certificate", TLSClientCA, TLSRedirectPort)
+	}
+
+	return cert, nil
+}
+
+// tlsConfig builds the server TLS configuration from the app.tls.* keys.
+// When a client CA bundle is given, the clients must present a certificate it signed.
+func tlsConfig(v *viper.Viper) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(v.GetString(TLSCert), v.GetString(TLSKey))
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if version := v.GetString(TLSMinVersion); version != "" {
+		min, ok := tlsVersions[version]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS min version %s", version)
+		}
+		config.MinVersion = min
+	}
+
+	if names := v.GetStringSlice(TLSCipherSuites); len(names) > 0 {
+		suites := map[string]uint16{}
+		for _, suite := range tls.CipherSuites() {
+			suites[suite.Name] = suite.ID
+		}
+
+		for _, name := range names {
+			id, ok := suites[name]
+			if !ok {
+				return nil, fmt.Errorf("invalid or insecure TLS cipher suite %s", name)
+			}
+			config.CipherSuites = append(config.CipherSuites, id)
+		}
+	}
+
+	if path := v.GetString(TLSClientCA); path != "" {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(buf) {
+			return nil, errors.New("invalid client CA bundle: no certificate found")
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// redirectHandler redirects the plain HTTP requests to the HTTPS port.
+func redirectHandler(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
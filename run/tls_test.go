@@ -0,0 +1,33 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestTLSEnabled(t *testing.T) {
+	cases := []struct {
+		settings map[string]interface{}
+		enabled  bool
+		ok       bool
+	}{
+		{settings: map[string]interface{}{}, ok: true},
+		{settings: map[string]interface{}{TLSCert: "cert.pem", TLSKey: "key.pem"}, enabled: true, ok: true},
+		{settings: map[string]interface{}{TLSCert: "cert.pem"}},
+		{settings: map[string]interface{}{TLSKey: "key.pem"}},
+		{settings: map[string]interface{}{TLSRedirectPort: 80}},
+	}
+
+	for _, c := range cases {
+		v := viper.New()
+		for key, value := range c.settings {
+			v.Set(key, value)
+		}
+
+		enabled, err := tlsEnabled(v)
+		if (err == nil) != c.ok || enabled != c.enabled {
+			t.Errorf("%v: got %t, %v", c.settings, enabled, err)
+		}
+	}
+}
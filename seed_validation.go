@@ -0,0 +1,264 @@
+package snakepit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SeedValidationError aggregates all the problems found in a seed.
+type SeedValidationError struct {
+	Problems []SeedProblem `json:"problems"`
+}
+
+// SeedProblem describes an invalid seed document.
+type SeedProblem struct {
+	Collection string `json:"collection"`
+	// The index of the document in the seed collection.
+	Index   int    `json:"index"`
+	Key     string `json:"key,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *SeedValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+
+	for i, p := range e.Problems {
+		location := fmt.Sprintf("%s[%d]", p.Collection, p.Index)
+		if p.Key != "" {
+			location += " (" + p.Key + ")"
+		}
+		if p.Field != "" {
+			location += "." + p.Field
+		}
+
+		lines[i] = location + ": " + p.Message
+	}
+
+	return fmt.Sprintf("invalid seed:\n  %s", strings.Join(lines, "\n  "))
+}
+
+// ValidateSeed checks every document of a seed before it is written: keys must be
+// set and unique in their collection, edges must link documents of the seed and the
+// fields must satisfy their `validate` tags. Supported rules are "required",
+// "min=N", "max=N" (length for strings and slices, value for numbers) and "regexp=EXPR".
+// The regexp rule must come last, as its expression spans the rest of the tag.
+// A nil naming strategy defaults to CamelCase.
+func ValidateSeed(seed Seed, naming NamingStrategy) error {
+	local := reflect.ValueOf(seed)
+
+	if local.Kind() != reflect.Ptr {
+		return errors.New("invalid seed type: not a pointer")
+	}
+
+	local = local.Elem()
+
+	if local.Kind() != reflect.Struct {
+		return errors.New("invalid seed type: not a struct")
+	}
+
+	docs := map[string][]map[string]interface{}{}
+	keys := map[string]map[string]bool{}
+
+	for i := 0; i < local.NumField(); i++ {
+		field := local.Field(i)
+
+		if field.Kind() != reflect.Slice {
+			continue
+		}
+
+		if field.Type().Elem().Kind() != reflect.Struct {
+			return errors.New("invalid seed field type: not a struct")
+		}
+
+		colName := collectionName(local.Type().Field(i), naming)
+
+		colDocs, err := toDocuments(field.Interface())
+		if err != nil {
+			return err
+		}
+
+		docs[colName] = colDocs
+		keys[colName] = map[string]bool{}
+
+		for _, doc := range colDocs {
+			if key, _ := doc["_key"].(string); key != "" {
+				keys[colName][key] = true
+			}
+		}
+	}
+
+	report := &SeedValidationError{}
+
+	for i := 0; i < local.NumField(); i++ {
+		field := local.Field(i)
+
+		if field.Kind() != reflect.Slice {
+			continue
+		}
+
+		colName := collectionName(local.Type().Field(i), naming)
+
+		_, edge := field.Type().Elem().FieldByName("From")
+		seen := map[string]bool{}
+
+		for j, doc := range docs[colName] {
+			key, _ := doc["_key"].(string)
+
+			problem := func(fieldName, format string, a ...interface{}) {
+				report.Problems = append(report.Problems, SeedProblem{
+					Collection: colName,
+					Index:      j,
+					Key:        key,
+					Field:      fieldName,
+					Message:    fmt.Sprintf(format, a...),
+				})
+			}
+
+			switch {
+			case key == "":
+				problem("_key", "missing key")
+			case seen[key]:
+				problem("_key", "duplicate key")
+			}
+			seen[key] = true
+
+			if edge {
+				for _, name := range []string{"_from", "_to"} {
+					ref, _ := doc[name].(string)
+
+					split := strings.SplitN(ref, "/", 2)
+					if len(split) != 2 {
+						problem(name, "invalid document reference %q", ref)
+						continue
+					}
+
+					if !keys[split[0]][split[1]] {
+						problem(name, "unknown seed document %q", ref)
+					}
+				}
+			}
+
+			elem := field.Index(j)
+
+			for k := 0; k < elem.NumField(); k++ {
+				tag := elem.Type().Field(k).Tag.Get("validate")
+				if tag == "" {
+					continue
+				}
+
+				for _, rule := range validationRules(tag) {
+					if msg := checkRule(elem.Field(k), rule); msg != "" {
+						problem(jsonFieldName(elem.Type().Field(k)), "%s", msg)
+					}
+				}
+			}
+		}
+	}
+
+	if len(report.Problems) > 0 {
+		return report
+	}
+
+	return nil
+}
+
+// validationRules splits a validate tag in rules. A "regexp=" rule consumes
+// the rest of the tag, so that its expression can contain commas.
+func validationRules(tag string) []string {
+	rules := []string{}
+
+	for tag != "" {
+		if strings.HasPrefix(tag, "regexp=") {
+			return append(rules, tag)
+		}
+
+		split := strings.SplitN(tag, ",", 2)
+		rules = append(rules, split[0])
+
+		tag = ""
+		if len(split) == 2 {
+			tag = split[1]
+		}
+	}
+
+	return rules
+}
+
+// checkRule returns a description of the violation of a validation rule by v,
+// or an empty string if the rule is satisfied.
+func checkRule(v reflect.Value, rule string) string {
+	split := strings.SplitN(rule, "=", 2)
+	name, param := split[0], ""
+	if len(split) == 2 {
+		param = split[1]
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if name == "required" {
+				return "cannot be empty"
+			}
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch name {
+	case "required":
+		switch v.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map:
+			if v.Len() == 0 {
+				return "cannot be empty"
+			}
+		default:
+			if reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface()) {
+				return "cannot be empty"
+			}
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid rule %q", rule)
+		}
+
+		var size float64
+
+		switch v.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+			size = float64(v.Len())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			size = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			size = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			size = v.Float()
+		default:
+			return fmt.Sprintf("invalid rule %q", rule)
+		}
+
+		if name == "min" && size < limit {
+			return fmt.Sprintf("cannot be less than %s", param)
+		}
+		if name == "max" && size > limit {
+			return fmt.Sprintf("cannot be more than %s", param)
+		}
+	case "regexp":
+		re, err := regexp.Compile(param)
+		if err != nil || v.Kind() != reflect.String {
+			return fmt.Sprintf("invalid rule %q", rule)
+		}
+
+		if !re.MatchString(v.String()) {
+			return fmt.Sprintf("must match %s", param)
+		}
+	default:
+		return fmt.Sprintf("unknown rule %q", rule)
+	}
+
+	return ""
+}
@@ -0,0 +1,45 @@
+package snakepit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidationRules(t *testing.T) {
+	cases := []struct {
+		tag   string
+		rules []string
+	}{
+		{"required", []string{"required"}},
+		{"required,min=2,max=5", []string{"required", "min=2", "max=5"}},
+		{"regexp=^[A-Z]{2,3}$", []string{"regexp=^[A-Z]{2,3}$"}},
+		{"required,regexp=^(a,b)$", []string{"required", "regexp=^(a,b)$"}},
+	}
+
+	for _, c := range cases {
+		if rules := validationRules(c.tag); !reflect.DeepEqual(rules, c.rules) {
+			t.Errorf("%q: got %q, want %q", c.tag, rules, c.rules)
+		}
+	}
+}
+
+func TestValidateSeedRegexpWithComma(t *testing.T) {
+	type country struct {
+		Key  string `json:"_key"`
+		Code string `json:"code" validate:"required,regexp=^[A-Z]{2,3}$"`
+	}
+	type seed struct {
+		Countries []country
+	}
+
+	if err := ValidateSeed(&seed{Countries: []country{{Key: "fr", Code: "FRA"}}}, nil); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	err := ValidateSeed(&seed{Countries: []country{{Key: "fr", Code: "F"}}}, nil)
+
+	report, ok := err.(*SeedValidationError)
+	if !ok || len(report.Problems) != 1 {
+		t.Fatalf("expected a single problem, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package snakepit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pressly/chi"
+	"golang.org/x/net/context"
+)
+
+const contextSpan CtxKey = "span"
+
+var (
+	traceParent = http.CanonicalHeaderKey("traceparent")
+	traceState  = http.CanonicalHeaderKey("tracestate")
+
+	traceParentRegexp = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+)
+
+// SpanExporter receives the spans once ended.
+type SpanExporter interface {
+	ExportSpan(span *Span)
+}
+
+// Span is a timed operation of a trace, identified following the W3C Trace Context format.
+type Span struct {
+	TraceID    string            `json:"traceId"`
+	SpanID     string            `json:"spanId"`
+	ParentID   string            `json:"parentId,omitempty"`
+	Name       string            `json:"name"`
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Sampled    bool              `json:"sampled"`
+	TraceState string            `json:"-"`
+
+	mutex    sync.Mutex
+	exporter SpanExporter
+}
+
+// SetAttribute tags the span with a key value pair.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.Attributes[key] = value
+}
+
+// Finish ends the span and exports it.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	s.End = time.Now()
+	s.mutex.Unlock()
+
+	if s.exporter != nil && s.Sampled {
+		s.exporter.ExportSpan(s)
+	}
+}
+
+// TraceParent returns the W3C traceparent header value identifying the span.
+func (s *Span) TraceParent() string {
+	flags := "00"
+	if s.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + s.TraceID + "-" + s.SpanID + "-" + flags
+}
+
+// GetSpan returns the current span from the given context if one is present.
+func GetSpan(ctx context.Context) (*Span, error) {
+	if ctx == nil {
+		return nil, errors.New("nil context")
+	}
+
+	span, ok := ctx.Value(contextSpan).(*Span)
+	if !ok {
+		return nil, errors.New("unexpected type")
+	}
+
+	if span == nil {
+		return nil, errors.New("nil value in context")
+	}
+
+	return span, nil
+}
+
+// StartSpan starts a child span of the current span of the context, and returns
+// a context holding it. Without current span, a new trace is started and the span
+// is not exported.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	span := &Span{
+		SpanID:     newTraceID(8),
+		Name:       name,
+		Start:      time.Now(),
+		Attributes: map[string]string{},
+	}
+
+	if parent, err := GetSpan(ctx); err == nil {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+		span.Sampled = parent.Sampled
+		span.TraceState = parent.TraceState
+		span.exporter = parent.exporter
+	} else {
+		span.TraceID = newTraceID(16)
+	}
+
+	return context.WithValue(ctx, contextSpan, span), span
+}
+
+// InjectTrace sets the traceparent and tracestate headers of an outgoing request
+// from the current span of the context, propagating the trace to another service.
+func InjectTrace(ctx context.Context, header http.Header) {
+	span, err := GetSpan(ctx)
+	if err != nil {
+		return
+	}
+
+	header.Set(traceParent, span.TraceParent())
+	if span.TraceState != "" {
+		header.Set(traceState, span.TraceState)
+	}
+}
+
+// Tracer is a middleware starting a span for each request. The trace is continued
+// from the inbound traceparent and tracestate headers when they are valid, and the
+// request span is echoed in the response traceparent header.
+type Tracer struct {
+	exporter SpanExporter
+}
+
+func NewTracer(exporter SpanExporter) func(next chi.Handler) chi.Handler {
+	tracer := &Tracer{exporter: exporter}
+	return tracer.middleware
+}
+
+func (t *Tracer) middleware(next chi.Handler) chi.Handler {
+	return chi.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		span := &Span{
+			SpanID:     newTraceID(8),
+			Name:       r.Method + " " + r.URL.Path,
+			Start:      time.Now(),
+			Attributes: map[string]string{},
+			Sampled:    true,
+			exporter:   t.exporter,
+		}
+
+		if m := traceParentRegexp.FindStringSubmatch(r.Header.Get(traceParent)); m != nil &&
+			m[1] != "ff" && strings.Trim(m[2], "0") != "" && strings.Trim(m[3], "0") != "" {
+			span.TraceID = m[2]
+			span.ParentID = m[3]
+			flags, _ := strconv.ParseUint(m[4], 16, 8)
+			span.Sampled = flags&1 == 1
+			span.TraceState = r.Header.Get(traceState)
+		} else {
+			span.TraceID = newTraceID(16)
+		}
+
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.url", r.URL.String())
+
+		w.Header().Set(traceParent, span.TraceParent())
+		if span.TraceState != "" {
+			w.Header().Set(traceState, span.TraceState)
+		}
+
+		ctx = context.WithValue(ctx, contextSpan, span)
+
+		proxy := wrapWriter(w)
+		defer func() {
+			span.SetAttribute("http.status_code", strconv.Itoa(proxy.status()))
+			span.Finish()
+		}()
+
+		next.ServeHTTPC(ctx, proxy, r)
+		proxy.maybeWriteHeader()
+	})
+}
+
+func newTraceID(size int) string {
+	buf := make([]byte, size)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// StdoutExporter writes the ended spans as JSON lines, typically to os.Stdout.
+type StdoutExporter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func NewStdoutExporter(w io.Writer) *StdoutExporter {
+	return &StdoutExporter{w: w}
+}
+
+func (e *StdoutExporter) ExportSpan(span *Span) {
+	buf, err := json.Marshal(span)
+	if err != nil {
+		return
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.w.Write(append(buf, '\n'))
+}
+
+// MemoryExporter keeps the ended spans in memory, mainly meant for tests.
+type MemoryExporter struct {
+	mutex sync.Mutex
+	spans []*Span
+}
+
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+func (e *MemoryExporter) ExportSpan(span *Span) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the exported spans, in their ending order.
+func (e *MemoryExporter) Spans() []*Span {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return append([]*Span{}, e.spans...)
+}
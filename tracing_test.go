@@ -0,0 +1,133 @@
+package snakepit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pressly/chi"
+	"golang.org/x/net/context"
+)
+
+func TestTracerInboundTraceParent(t *testing.T) {
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+
+	cases := []struct {
+		name        string
+		traceparent string
+		tracestate  string
+		continued   bool
+		sampled     bool
+	}{
+		{name: "sampled", traceparent: "00-" + traceID + "-" + parentID + "-01", tracestate: "vendor=abc", continued: true, sampled: true},
+		{name: "not sampled", traceparent: "00-" + traceID + "-" + parentID + "-00", tracestate: "vendor=abc", continued: true},
+		{name: "other flags", traceparent: "00-" + traceID + "-" + parentID + "-03", continued: true, sampled: true},
+		{name: "other flags not sampled", traceparent: "00-" + traceID + "-" + parentID + "-02", continued: true},
+		{name: "future version", traceparent: "01-" + traceID + "-" + parentID + "-01", continued: true, sampled: true},
+		{name: "version ff", traceparent: "ff-" + traceID + "-" + parentID + "-01", tracestate: "vendor=abc", sampled: true},
+		{name: "zero trace ID", traceparent: "00-" + strings.Repeat("0", 32) + "-" + parentID + "-01", sampled: true},
+		{name: "zero parent ID", traceparent: "00-" + traceID + "-" + strings.Repeat("0", 16) + "-01", sampled: true},
+		{name: "uppercase hex", traceparent: "00-" + strings.ToUpper(traceID) + "-" + parentID + "-01", sampled: true},
+		{name: "short trace ID", traceparent: "00-" + traceID[1:] + "-" + parentID + "-01", sampled: true},
+		{name: "missing", tracestate: "vendor=abc", sampled: true},
+	}
+
+	for _, c := range cases {
+		exporter := NewMemoryExporter()
+
+		var span, child *Span
+		handler := NewTracer(exporter)(chi.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			span, _ = GetSpan(ctx)
+			_, child = StartSpan(ctx, "query")
+			child.Finish()
+		}))
+
+		r := httptest.NewRequest("GET", "/users", nil)
+		if c.traceparent != "" {
+			r.Header.Set("traceparent", c.traceparent)
+		}
+		if c.tracestate != "" {
+			r.Header.Set("tracestate", c.tracestate)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTPC(context.Background(), w, r)
+
+		if span == nil {
+			t.Fatalf("%s: no span in the context", c.name)
+		}
+
+		if continued := span.TraceID == traceID && span.ParentID == parentID; continued != c.continued {
+			t.Errorf("%s: got trace %s and parent %q, want continued %t", c.name, span.TraceID, span.ParentID, c.continued)
+		}
+		if !c.continued && (len(span.TraceID) != 32 || span.ParentID != "") {
+			t.Errorf("%s: got trace %q and parent %q, want a new trace", c.name, span.TraceID, span.ParentID)
+		}
+		if span.Sampled != c.sampled {
+			t.Errorf("%s: got sampled %t, want %t", c.name, span.Sampled, c.sampled)
+		}
+
+		wantState := ""
+		if c.continued {
+			wantState = c.tracestate
+		}
+		if state := w.Header().Get("tracestate"); state != wantState || span.TraceState != wantState {
+			t.Errorf("%s: got tracestate %q, want %q", c.name, state, wantState)
+		}
+
+		if header := w.Header().Get("traceparent"); header != span.TraceParent() {
+			t.Errorf("%s: got traceparent %q, want %q", c.name, header, span.TraceParent())
+		}
+
+		if child.TraceID != span.TraceID || child.ParentID != span.SpanID || child.Sampled != span.Sampled {
+			t.Errorf("%s: child span %+v not linked to %+v", c.name, child, span)
+		}
+
+		spans := exporter.Spans()
+		if !c.sampled {
+			if len(spans) != 0 {
+				t.Errorf("%s: got %d exported spans, want none", c.name, len(spans))
+			}
+			continue
+		}
+
+		if len(spans) != 2 || spans[0] != child || spans[1] != span {
+			t.Errorf("%s: got exported spans %+v, want the child then the request span", c.name, spans)
+		}
+		if span.Attributes["http.status_code"] != "200" {
+			t.Errorf("%s: got status attribute %q", c.name, span.Attributes["http.status_code"])
+		}
+	}
+}
+
+func TestStartSpanWithoutParent(t *testing.T) {
+	ctx, span := StartSpan(context.Background(), "job")
+
+	if current, err := GetSpan(ctx); err != nil || current != span {
+		t.Errorf("got %v, %v, want the started span", current, err)
+	}
+
+	if len(span.TraceID) != 32 || len(span.SpanID) != 16 || span.ParentID != "" || span.Sampled {
+		t.Errorf("got %+v, want a new unsampled trace", span)
+	}
+
+	// Without exporter, finishing the span is a no-op.
+	span.Finish()
+}
+
+func TestInjectTrace(t *testing.T) {
+	ctx, span := StartSpan(context.Background(), "job")
+	span.Sampled = true
+	span.TraceState = "vendor=abc"
+
+	header := http.Header{}
+	InjectTrace(ctx, header)
+
+	if header.Get("traceparent") != "00-"+span.TraceID+"-"+span.SpanID+"-01" || header.Get("tracestate") != "vendor=abc" {
+		t.Errorf("got headers %v", header)
+	}
+}
@@ -13,6 +13,7 @@ type writerProxy interface {
 	http.ResponseWriter
 	maybeWriteHeader()
 	status() int
+	bytesWritten() int
 }
 
 // basicWriter holds the status code and a
@@ -21,6 +22,7 @@ type basicWriter struct {
 	http.ResponseWriter
 	wroteHeader bool
 	code        int
+	bytes       int
 }
 
 // WriteHeader stores the status code and writes header
@@ -35,7 +37,9 @@ func (b *basicWriter) WriteHeader(code int) {
 // Write writes the bytes and calls MaybeWriteHeader
 func (b *basicWriter) Write(buf []byte) (int, error) {
 	b.maybeWriteHeader()
-	return b.ResponseWriter.Write(buf)
+	n, err := b.ResponseWriter.Write(buf)
+	b.bytes += n
+	return n, err
 }
 
 // maybeWriteHeader writes the header if it is not alredy set
@@ -50,6 +54,11 @@ func (b *basicWriter) status() int {
 	return b.code
 }
 
+// bytesWritten returns the number of bytes written in the body
+func (b *basicWriter) bytesWritten() int {
+	return b.bytes
+}
+
 // unwrap returns the original http.ResponseWriter
 func (b *basicWriter) Unwrap() http.ResponseWriter {
 	return b.ResponseWriter